@@ -18,6 +18,23 @@ type GameAPI interface {
 	// StartRound initialises a new round with fresh words and resets session stats.
 	StartRound()
 
+	// StartTimer starts the round's timer without waiting for the first
+	// correct key, for rounds started by a countdown. The seek time of the
+	// first key is then recorded as the player's reaction time.
+	StartTimer()
+
+	// PauseRound marks the round as paused. The frontend excludes paused time
+	// from its timing; the seek time of the first key after the pause spans
+	// it, so that key is left out of the letter and bigram timing stats.
+	PauseRound()
+
+	// AbandonRound gives up a round in progress, recording the abandonment
+	// in the historical stats. With keepPartial, the accuracy and timing of
+	// the letters typed so far are merged too; WPM, time and bests never
+	// are. Returns false if no round was in progress. Call StartRound to
+	// play on.
+	AbandonRound(keepPartial bool) bool
+
 	// Input Handling
 	// ---------------
 
@@ -57,6 +74,10 @@ type GameAPI interface {
 	// GetHistoricalStats returns the historical statistics.
 	GetHistoricalStats() *stats.HistoricalStats
 
+	// GetTrends returns rolling averages, improvement rate and per-letter
+	// accuracy trends computed from the recorded round history.
+	GetTrends() stats.TrendReport
+
 	// Persistence
 	// -----------
 
@@ -104,6 +125,17 @@ type GameState struct {
 	// TimerStarted indicates whether the game timer has started.
 	TimerStarted bool
 
+	// Paused indicates the round is paused. Like LiveWPM it is tracked by
+	// the frontend, which overrides this value.
+	Paused bool
+
+	// Countdown is the seconds left before a countdown start reaches "go",
+	// or zero. It is tracked by the frontend.
+	Countdown int
+
+	// QuoteSource attributes the passage being typed in quote mode.
+	QuoteSource string
+
 	// PunctuationMode indicates whether punctuation mode is enabled.
 	PunctuationMode bool
 
@@ -138,6 +170,20 @@ type Config struct {
 
 	// CharactersPerRound is the target total characters per round.
 	CharactersPerRound int
+
+	// QuoteMode plays passages from the bundled quote corpus instead of
+	// common words. PunctuationMode is ignored, as quotes keep their own.
+	QuoteMode bool
+
+	// QuoteLength limits quote mode to one length category
+	// (words.QuoteLengthShort, ...); empty for any length.
+	QuoteLength string
+
+	// Storage is the historical stats backend (stats.StorageJSON or stats.StorageKV).
+	Storage string
+
+	// Hooks are notified of every completed round by a server.
+	Hooks HookConfig
 }
 
 // DefaultConfig returns the default game configuration.
@@ -146,5 +192,6 @@ func DefaultConfig() Config {
 		PunctuationMode:    false,
 		WordsPerRound:      30,
 		CharactersPerRound: 150,
+		Storage:            stats.StorageJSON,
 	}
 }
@@ -0,0 +1,224 @@
+package backend
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/timlinux/baboon/schema"
+	"github.com/timlinux/baboon/stats"
+)
+
+// TokensSchemaVersion is the current version of the tokens file format
+const TokensSchemaVersion = 1
+
+// Tokens holds the bearer token for each profile. A token grants access to
+// its own profile's sessions and stats only. The file is re-read when it
+// changes on disk, so tokens issued by "baboon token" take effect on a
+// running server.
+type Tokens struct {
+	path string
+
+	mu       sync.Mutex
+	profiles map[string]string // Profile name to token
+	modTime  time.Time         // Of the file when last read
+}
+
+// tokensFile is the on-disk form of Tokens
+type tokensFile struct {
+	Version  int               `json:"version"`
+	Profiles map[string]string `json:"profiles"`
+}
+
+// GetTokensPath returns the path to the tokens file
+func GetTokensPath() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	dir := filepath.Join(homeDir, ".config", "baboon")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "tokens.json"), nil
+}
+
+// LoadTokens reads the tokens file at path. On first run, when there is no
+// file, one is written holding a new token for the default profile.
+func LoadTokens(path string) (*Tokens, error) {
+	t := &Tokens{path: path, profiles: map[string]string{}}
+	if err := t.reload(); err != nil {
+		return nil, err
+	}
+	if _, err := t.Token(stats.DefaultProfile); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// Token returns the token for a profile, issuing one if it has none.
+func (t *Tokens) Token(profile string) (string, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if err := t.reload(); err != nil {
+		return "", err
+	}
+	if token, ok := t.profiles[profile]; ok {
+		return token, nil
+	}
+	return t.issue(profile)
+}
+
+// Rotate replaces a profile's token, so the old one is no longer accepted.
+func (t *Tokens) Rotate(profile string) (string, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if err := t.reload(); err != nil {
+		return "", err
+	}
+	return t.issue(profile)
+}
+
+// Profile returns the profile a token belongs to.
+func (t *Tokens) Profile(token string) (string, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	// A file that cannot be re-read keeps the tokens last loaded
+	t.reload()
+	for profile, candidate := range t.profiles {
+		if subtle.ConstantTimeCompare([]byte(candidate), []byte(token)) == 1 {
+			return profile, true
+		}
+	}
+	return "", false
+}
+
+// issue generates and saves a new token for profile. It must be called with
+// t.mu held.
+func (t *Tokens) issue(profile string) (string, error) {
+	if !stats.ValidProfileName(profile) {
+		return "", fmt.Errorf("invalid profile name %q", profile)
+	}
+	bytes := make([]byte, 32)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	token := hex.EncodeToString(bytes)
+	t.profiles[profile] = token
+
+	data, err := json.MarshalIndent(tokensFile{Version: TokensSchemaVersion, Profiles: t.profiles}, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	// Tokens are credentials: only the owner may read them
+	if err := os.WriteFile(t.path, data, 0600); err != nil {
+		return "", err
+	}
+	if info, err := os.Stat(t.path); err == nil {
+		t.modTime = info.ModTime()
+	}
+	return token, nil
+}
+
+// reload re-reads the file if it has changed since it was last read. It must
+// be called with t.mu held, except by LoadTokens.
+func (t *Tokens) reload() error {
+	info, err := os.Stat(t.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if info.ModTime().Equal(t.modTime) {
+		return nil
+	}
+
+	data, err := os.ReadFile(t.path)
+	if err != nil {
+		return err
+	}
+	version, err := schema.Version(data)
+	if err != nil {
+		return err
+	}
+	if version > TokensSchemaVersion {
+		return schema.ErrNewerVersion
+	}
+	var file tokensFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return err
+	}
+	if file.Profiles == nil {
+		file.Profiles = map[string]string{}
+	}
+	t.profiles = file.Profiles
+	t.modTime = info.ModTime()
+	return nil
+}
+
+// profileKey is the request context key for the authenticated profile
+type profileKey struct{}
+
+// RequireTokens makes every route except the health check and metrics
+// require a bearer token from tokens. A server without tokens accepts all requests, as the
+// in-process harnesses do.
+func (s *Server) RequireTokens(tokens *Tokens) {
+	s.tokens = tokens
+}
+
+// authenticate wraps a handler so that it only runs for requests carrying a
+// valid token, and only on sessions belonging to the token's profile.
+//
+// Browsers cannot set headers on a WebSocket handshake, so the token may
+// also be given in the access_token query parameter.
+func (s *Server) authenticate(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.tokens == nil {
+			next(w, r)
+			return
+		}
+
+		token, found := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !found {
+			token = r.URL.Query().Get("access_token")
+		}
+		profile, ok := s.tokens.Profile(token)
+		if token == "" || !ok {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="baboon"`)
+			writeError(w, &APIError{Message: "missing or invalid token", Code: CodeUnauthorized, Status: http.StatusUnauthorized})
+			return
+		}
+
+		if id := r.PathValue("id"); id != "" {
+			s.mu.RLock()
+			session, exists := s.sessions[id]
+			s.mu.RUnlock()
+			if exists && session.Profile != profile {
+				writeError(w, errForbidden("session belongs to another profile"))
+				return
+			}
+		}
+
+		next(w, r.WithContext(context.WithValue(r.Context(), profileKey{}, profile)))
+	}
+}
+
+// authenticatedProfile returns the profile of the token a request carried.
+// It returns false if the server does not require tokens.
+func authenticatedProfile(r *http.Request) (string, bool) {
+	profile, ok := r.Context().Value(profileKey{}).(string)
+	return profile, ok
+}
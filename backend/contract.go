@@ -0,0 +1,374 @@
+package backend
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/timlinux/baboon/stats"
+	"github.com/timlinux/baboon/websocket"
+)
+
+// CheckContract exercises every route against an in-process server and
+// checks the responses against the OpenAPI document: each route must answer
+// with its documented status, and every response body must match its schema
+// exactly, with no undocumented or missing fields. Requests without a token,
+// or with another profile's, must be refused. Stats and tokens are written to
+// dir. It returns one message per disagreement.
+func CheckContract(dir string) ([]string, error) {
+	server, err := NewServer(DefaultConfig(), "")
+	if err != nil {
+		return nil, err
+	}
+	server.history = newHistoryStoreWith(func(profile string) (stats.StatsStore, error) {
+		return stats.NewJSONStore(filepath.Join(dir, "stats-"+profile+".json")), nil
+	})
+	tokens, err := LoadTokens(filepath.Join(dir, "tokens.json"))
+	if err != nil {
+		return nil, err
+	}
+	server.RequireTokens(tokens)
+	listener := httptest.NewServer(server.routes())
+	defer listener.Close()
+
+	doc, err := publishedDocument(server)
+	if err != nil {
+		return nil, err
+	}
+	c := &contractChecker{doc: doc, baseURL: listener.URL, http: listener.Client()}
+	if c.token, err = tokens.Token(stats.DefaultProfile); err != nil {
+		return nil, err
+	}
+	if c.otherToken, err = tokens.Token("other"); err != nil {
+		return nil, err
+	}
+
+	// Create the session the other routes use first, and delete it last
+	var first, middle, last []route
+	for _, r := range server.routeTable() {
+		switch {
+		case r.method == "POST" && r.path == "/api/sessions":
+			first = append(first, r)
+		case r.method == "DELETE":
+			last = append(last, r)
+		default:
+			middle = append(middle, r)
+		}
+	}
+	for _, r := range append(append(first, middle...), last...) {
+		c.check(r)
+	}
+	return c.problems, nil
+}
+
+// publishedDocument returns the OpenAPI document as a client sees it.
+func publishedDocument(server *Server) (map[string]any, error) {
+	data, err := json.Marshal(server.OpenAPI())
+	if err != nil {
+		return nil, err
+	}
+	var doc map[string]any
+	err = json.Unmarshal(data, &doc)
+	return doc, err
+}
+
+// contractChecker holds the state of a contract check.
+type contractChecker struct {
+	doc        map[string]any
+	baseURL    string
+	http       *http.Client
+	token      string // For the default profile, which owns the session
+	otherToken string // For a profile with no access to the session
+	sessionID  string
+	problems   []string
+}
+
+func (c *contractChecker) fail(r route, format string, args ...any) {
+	c.problems = append(c.problems, r.method+" "+r.path+": "+fmt.Sprintf(format, args...))
+}
+
+// check calls a route with its example request and validates the response.
+func (c *contractChecker) check(r route) {
+	op, ok := lookup(c.doc, "paths", r.path, strings.ToLower(r.method)).(map[string]any)
+	if !ok {
+		c.fail(r, "not in the OpenAPI document")
+		return
+	}
+	responses, _ := op["responses"].(map[string]any)
+	path := strings.ReplaceAll(r.path, "{id}", c.sessionID)
+
+	// Documented error responses
+	c.checkError(r, responses, http.StatusUnauthorized, "request without a token",
+		"", r.method, path, r.request)
+	c.checkError(r, responses, http.StatusForbidden, "another profile's token",
+		c.otherToken, r.method, path, r.request)
+	c.checkError(r, responses, http.StatusNotFound, "unknown session",
+		c.token, r.method, strings.ReplaceAll(r.path, "{id}", "no-such-session"), r.request)
+	c.checkError(r, responses, http.StatusBadRequest, "malformed body",
+		c.token, r.method, path, json.RawMessage("{"))
+	oversized := json.RawMessage(`{"padding":"` + strings.Repeat("x", MaxRequestBytes) + `"}`)
+	c.checkError(r, responses, http.StatusRequestEntityTooLarge, "oversized body",
+		c.token, r.method, path, oversized)
+
+	if r.path == "/api/sessions/{id}/ws" {
+		c.checkWebSocket(r, path)
+		return
+	}
+
+	status, contentType, data := c.call(c.token, r.method, path, r.request)
+	response, ok := responses[strconv.Itoa(status)].(map[string]any)
+	if !ok || status != r.successStatus() {
+		c.fail(r, "returned undocumented status %d", status)
+		return
+	}
+	if lookup(response, "content", "text/plain") != nil {
+		if !strings.HasPrefix(contentType, "text/plain") {
+			c.fail(r, "returned Content-Type %q, documented text/plain", contentType)
+		}
+		return
+	}
+	media, documented := lookup(response, "content", "application/json").(map[string]any)
+	if !documented {
+		if len(bytes.TrimSpace(data)) > 0 {
+			c.fail(r, "returned a body but none is documented")
+		}
+		return
+	}
+	if !strings.HasPrefix(contentType, "application/json") {
+		c.fail(r, "returned Content-Type %q, documented application/json", contentType)
+	}
+
+	var value any
+	if err := json.Unmarshal(data, &value); err != nil {
+		c.fail(r, "invalid JSON response: %v", err)
+		return
+	}
+	schema, _ := media["schema"].(map[string]any)
+	for _, problem := range c.validate(value, schema, "response") {
+		c.fail(r, "%s", problem)
+	}
+
+	if r.method == "POST" && r.path == "/api/sessions" {
+		if m, ok := value.(map[string]any); ok {
+			c.sessionID, _ = m["session_id"].(string)
+		}
+	}
+}
+
+// checkError makes a request that should fail with status, if the route
+// documents it, and checks the error body against its schema.
+func (c *contractChecker) checkError(r route, responses map[string]any, status int, what, token, method, path string, body any) {
+	response, ok := responses[strconv.Itoa(status)].(map[string]any)
+	if !ok {
+		return
+	}
+	got, contentType, data := c.call(token, method, path, body)
+	if got != status {
+		c.fail(r, "%s returned %d, documented %d", what, got, status)
+		return
+	}
+	if !strings.HasPrefix(contentType, "application/json") {
+		c.fail(r, "%s returned Content-Type %q, documented application/json", what, contentType)
+		return
+	}
+	var value any
+	if err := json.Unmarshal(data, &value); err != nil {
+		c.fail(r, "%s returned invalid JSON: %v", what, err)
+		return
+	}
+	schema, _ := lookup(response, "content", "application/json", "schema").(map[string]any)
+	for _, problem := range c.validate(value, schema, "error") {
+		c.fail(r, "%s: %s", what, problem)
+	}
+}
+
+// checkWebSocket checks the upgrade succeeds with the documented status.
+func (c *contractChecker) checkWebSocket(r route, path string) {
+	dialer := websocket.Dialer{
+		Timeout:     5 * time.Second,
+		Subprotocol: WSSubprotocol,
+		Header:      http.Header{"Authorization": {"Bearer " + c.token}},
+	}
+	conn, err := dialer.Dial("ws" + strings.TrimPrefix(c.baseURL, "http") + path)
+	if err != nil {
+		c.fail(r, "upgrade failed: %v", err)
+		return
+	}
+	conn.Close()
+}
+
+// call makes a request with a bearer token, if one is given, returning the
+// status, content type and body.
+func (c *contractChecker) call(token, method, path string, body any) (int, string, []byte) {
+	var reader io.Reader
+	if raw, ok := body.(json.RawMessage); ok {
+		// Sent as is, so that malformed bodies can be tested
+		reader = bytes.NewReader(raw)
+	} else if body != nil {
+		data, _ := json.Marshal(body)
+		reader = bytes.NewReader(data)
+	}
+	req, _ := http.NewRequest(method, c.baseURL+path, reader)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return 0, "", nil
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, resp.Header.Get("Content-Type"), data
+}
+
+// validate checks a decoded JSON value against a schema from the document.
+func (c *contractChecker) validate(value any, schema map[string]any, at string) []string {
+	if ref, ok := schema["$ref"].(string); ok {
+		name := strings.TrimPrefix(ref, "#/components/schemas/")
+		resolved, _ := lookup(c.doc, "components", "schemas", name).(map[string]any)
+		return c.validate(value, resolved, at)
+	}
+	if all, ok := schema["allOf"].([]any); ok {
+		if value == nil && schema["nullable"] == true {
+			return nil
+		}
+		var problems []string
+		for _, s := range all {
+			sub, _ := s.(map[string]any)
+			problems = append(problems, c.validate(value, sub, at)...)
+		}
+		return problems
+	}
+
+	if value == nil {
+		if schema["nullable"] == true || len(schema) == 0 {
+			return nil
+		}
+		return []string{at + " is null but not nullable"}
+	}
+
+	typ, _ := schema["type"].(string)
+	switch typ {
+	case "object":
+		object, ok := value.(map[string]any)
+		if !ok {
+			return []string{fmt.Sprintf("%s is %T, documented object", at, value)}
+		}
+		return c.validateObject(object, schema, at)
+	case "array":
+		array, ok := value.([]any)
+		if !ok {
+			return []string{fmt.Sprintf("%s is %T, documented array", at, value)}
+		}
+		items, _ := schema["items"].(map[string]any)
+		var problems []string
+		for i, item := range array {
+			problems = append(problems, c.validate(item, items, fmt.Sprintf("%s[%d]", at, i))...)
+		}
+		return problems
+	case "string":
+		if _, ok := value.(string); !ok {
+			return []string{fmt.Sprintf("%s is %T, documented string", at, value)}
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return []string{fmt.Sprintf("%s is %T, documented boolean", at, value)}
+		}
+	case "number", "integer":
+		n, ok := value.(float64)
+		if !ok {
+			return []string{fmt.Sprintf("%s is %T, documented %s", at, value, typ)}
+		}
+		if typ == "integer" && n != math.Trunc(n) {
+			return []string{fmt.Sprintf("%s is %v, documented integer", at, n)}
+		}
+	}
+	return nil
+}
+
+func (c *contractChecker) validateObject(object map[string]any, schema map[string]any, at string) []string {
+	var problems []string
+	properties, _ := schema["properties"].(map[string]any)
+	additional, _ := schema["additionalProperties"].(map[string]any)
+
+	for _, name := range sortedFields(object) {
+		field := at + "." + name
+		if property, ok := properties[name].(map[string]any); ok {
+			problems = append(problems, c.validate(object[name], property, field)...)
+		} else if additional != nil {
+			problems = append(problems, c.validate(object[name], additional, field)...)
+		} else if properties != nil {
+			problems = append(problems, field+" is not documented")
+		}
+	}
+
+	required, _ := schema["required"].([]any)
+	for _, name := range required {
+		if _, ok := object[name.(string)]; !ok {
+			problems = append(problems, fmt.Sprintf("%s.%s is documented as required but missing", at, name))
+		}
+	}
+	return problems
+}
+
+// webClientCall matches the fetch calls in web/src/api.js, capturing the
+// path and the method, if one is given.
+var webClientCall = regexp.MustCompile("fetch\\(`\\$\\{this\\.baseUrl\\}([^`]*)`(?:,\\s*\\{\\s*method:\\s*'(\\w+)')?")
+
+// CheckWebClient checks that every endpoint called by the web client's
+// api.js exists in the OpenAPI document, returning one message per call
+// that does not.
+func CheckWebClient(source string) []string {
+	doc, err := publishedDocument(&Server{})
+	if err != nil {
+		return []string{err.Error()}
+	}
+
+	var problems []string
+	for _, match := range webClientCall.FindAllStringSubmatch(source, -1) {
+		path := "/api" + strings.ReplaceAll(match[1], "${this.sessionId}", "{id}")
+		method := match[2]
+		if method == "" {
+			method = "GET"
+		}
+		if lookup(doc, "paths", path, strings.ToLower(method)) == nil {
+			problems = append(problems, fmt.Sprintf("web client calls %s %s, which is not in the OpenAPI document", method, path))
+		}
+	}
+	return problems
+}
+
+// lookup follows keys through nested JSON objects, returning nil if any is missing.
+func lookup(value any, keys ...string) any {
+	for _, key := range keys {
+		object, ok := value.(map[string]any)
+		if !ok {
+			return nil
+		}
+		value = object[key]
+	}
+	return value
+}
+
+// sortedFields returns an object's field names in order, for stable messages.
+func sortedFields(object map[string]any) []string {
+	names := make([]string, 0, len(object))
+	for name := range object {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
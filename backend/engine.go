@@ -3,6 +3,7 @@ package backend
 import (
 	"fmt"
 	"math/rand"
+	"strings"
 	"time"
 
 	"github.com/timlinux/baboon/stats"
@@ -14,51 +15,79 @@ var punctuationChars = []string{",", ".", ";", ":", "!", "?"}
 
 // Engine implements the GameAPI interface and manages all game logic.
 type Engine struct {
-	config     Config
-	rng        *rand.Rand
-	historical *stats.HistoricalStats
-	session    *stats.Stats
-	words      []string
-	wordIdx    int
-	input      string
-	started    bool
+	config  Config
+	rng     *rand.Rand
+	history *ProfileHistory
+	session *stats.Stats
+	words   []string
+	wordIdx int
+	input   string
+	started bool
 
 	// lastLetter is tracked here for bigram/SFB detection (not timing related)
 	lastLetter string
 
+	// wordTimeMs accumulates frontend seek times for the current word
+	// so each completed word can be plotted on the speed curve
+	wordTimeMs int64
+
+	// wordMaxInput is the longest the input has been for the current word;
+	// keystrokes below this length are retypes after a backspace
+	wordMaxInput int
+
 	// recordedCorrect tracks which character positions have been recorded as correct
 	// to prevent double-counting when user backspaces and retypes
 	// Key format: "wordIdx:charIdx"
 	recordedCorrect map[string]bool
+
+	// paused is set by PauseRound until the next keystroke or space, whose
+	// seek time spans the pause
+	paused bool
+
+	// awaitingReaction is set by StartTimer until the first key, whose
+	// seek time is the reaction time
+	awaitingReaction bool
 }
 
-// NewEngine creates a new game engine with the given configuration.
+// NewEngine creates a new game engine with the given configuration,
+// using its own copy of the default profile's historical stats.
 func NewEngine(config Config) (*Engine, error) {
-	historical, err := stats.LoadHistoricalStats()
+	history, err := LoadProfileHistory(config.Storage, stats.DefaultProfile)
 	if err != nil {
 		return nil, err
 	}
+	return NewEngineWithHistory(config, history), nil
+}
 
+// NewEngineWithHistory creates a new game engine that records rounds into
+// a shared profile history.
+func NewEngineWithHistory(config Config, history *ProfileHistory) *Engine {
 	e := &Engine{
-		config:     config,
-		rng:        rand.New(rand.NewSource(time.Now().UnixNano())),
-		historical: historical,
+		config:  config,
+		rng:     rand.New(rand.NewSource(time.Now().UnixNano())),
+		history: history,
 	}
 
 	e.StartRound()
-	return e, nil
+	return e
 }
 
 // StartRound initialises a new round with fresh words and resets session stats.
 func (e *Engine) StartRound() {
-	// Get letter data for weighted word selection
-	letterData := e.getLetterData()
-	e.words = words.GetRandomWordsFixedCount(
-		e.config.WordsPerRound,
-		e.config.CharactersPerRound,
-		e.rng.Intn,
-		letterData,
-	)
+	var quote words.Quote
+	if e.config.QuoteMode {
+		quote = words.RandomQuote(e.config.QuoteLength, e.rng.Intn)
+		e.words = quote.Words()
+	} else {
+		// Get letter data for weighted word selection
+		letterData := e.getLetterData()
+		e.words = words.GetRandomWordsFixedCount(
+			e.config.WordsPerRound,
+			e.config.CharactersPerRound,
+			e.rng.Intn,
+			letterData,
+		)
+	}
 
 	// Create new session stats
 	e.session = &stats.Stats{
@@ -70,6 +99,9 @@ func (e *Engine) StartRound() {
 		RowStats:          make(map[int]stats.RowStat),
 		ErrorSubstitution: make(map[string]map[string]int),
 		SeekTimes:         make([]int64, 0),
+		WordWPM:           make([]float64, 0),
+		QuoteID:           quote.ID,
+		QuoteSource:       quote.Source,
 	}
 
 	// Reset tracking for correct character positions
@@ -77,8 +109,8 @@ func (e *Engine) StartRound() {
 
 	// Record all letters as presented (before adding punctuation)
 	for _, word := range e.words {
-		for _, char := range word {
-			if char >= 'a' && char <= 'z' {
+		for _, c := range word {
+			if char, ok := foldLetter(c); ok {
 				e.session.RecordLetterPresented(string(char))
 				if finger := stats.GetFinger(char); finger >= 0 {
 					e.session.RecordFingerPresented(finger)
@@ -94,7 +126,7 @@ func (e *Engine) StartRound() {
 	}
 
 	// Add punctuation if enabled
-	if e.config.PunctuationMode {
+	if e.config.PunctuationMode && !e.config.QuoteMode {
 		for i := 0; i < len(e.words)-1; i++ {
 			punct := punctuationChars[e.rng.Intn(len(punctuationChars))]
 			e.words[i] = e.words[i] + punct
@@ -105,6 +137,112 @@ func (e *Engine) StartRound() {
 	e.input = ""
 	e.started = false
 	e.lastLetter = ""
+	e.wordTimeMs = 0
+	e.wordMaxInput = 0
+	e.paused = false
+	e.awaitingReaction = false
+}
+
+// StartTimer starts the timer of a round that has not started yet.
+func (e *Engine) StartTimer() {
+	if e.started || e.wordIdx >= len(e.words) {
+		return
+	}
+	e.started = true
+	e.awaitingReaction = true
+}
+
+// recordReaction records the seek time of the first key after StartTimer
+// as the reaction time. Times that span a pause or look like the player was
+// distracted are discarded, as seek times are.
+func (e *Engine) recordReaction(seekTimeMs int64, spansPause bool) {
+	if !e.awaitingReaction {
+		return
+	}
+	e.awaitingReaction = false
+	if !spansPause && seekTimeMs > 0 && seekTimeMs < 5000 {
+		e.session.ReactionTimeMs = seekTimeMs
+	}
+}
+
+// PauseRound marks the round as paused. Pausing before the timer starts
+// has no effect, as there is no seek time to discard.
+func (e *Engine) PauseRound() {
+	if e.started {
+		e.paused = true
+	}
+}
+
+// AbandonRound gives up the round in progress. A round is in progress once
+// the timer has started and until its last word is completed.
+func (e *Engine) AbandonRound(keepPartial bool) bool {
+	if !e.started || e.wordIdx >= len(e.words) {
+		return false
+	}
+
+	if keepPartial {
+		partial := e.session
+		partial.EndTime = time.Now()
+		partial.Abandoned = true
+		e.countReached(partial)
+		partial.CalculateSpeedBreakdown()
+		e.history.Record(partial)
+	} else {
+		e.history.RecordAbandoned()
+		e.session = &stats.Stats{EndTime: time.Now(), Abandoned: true}
+	}
+
+	// Leave no round to type into until the next one starts
+	e.wordIdx = len(e.words)
+	e.input = ""
+	e.started = false
+	e.paused = false
+	return true
+}
+
+// countReached replaces the presented counts, which StartRound filled in
+// for the whole round, with counts of only the letters reached before it
+// was abandoned, so the words never typed don't count as misses.
+func (e *Engine) countReached(session *stats.Stats) {
+	for letter, s := range session.LetterAccuracy {
+		s.Presented = 0
+		session.LetterAccuracy[letter] = s
+	}
+	for finger, s := range session.FingerStats {
+		s.Presented = 0
+		session.FingerStats[finger] = s
+	}
+	for hand, s := range session.HandStats {
+		s.Presented = 0
+		session.HandStats[hand] = s
+	}
+	for row, s := range session.RowStats {
+		s.Presented = 0
+		session.RowStats[row] = s
+	}
+
+	// Letters in the current word count up to the furthest position typed,
+	// which covers every position ever recorded as correct
+	reached := strings.Join(e.words[:e.wordIdx], "")
+	current := e.words[e.wordIdx]
+	reached += current[:min(max(len(e.input), e.wordMaxInput), len(current))]
+
+	for _, c := range reached {
+		char, ok := foldLetter(c)
+		if !ok {
+			continue
+		}
+		session.RecordLetterPresented(string(char))
+		if finger := stats.GetFinger(char); finger >= 0 {
+			session.RecordFingerPresented(finger)
+		}
+		if hand := stats.GetHand(char); hand >= 0 {
+			session.RecordHandPresented(hand)
+		}
+		if row := stats.GetRow(char); row >= 0 {
+			session.RecordRowPresented(row)
+		}
+	}
 }
 
 // ProcessKeystroke handles a character input from the user (legacy, no timing).
@@ -124,6 +262,12 @@ func (e *Engine) ProcessKeystrokeWithTiming(char string, seekTimeMs int64) Keyst
 	inputIdx := len(e.input)
 	result := KeystrokeResult{CharIndex: inputIdx}
 
+	// The frontend has already excluded the paused time from the word's
+	// duration, but the gap still includes stepping away and coming back
+	spansPause := e.paused
+	e.paused = false
+	e.recordReaction(seekTimeMs, spansPause)
+
 	// Check if this should start the timer (first correct character of first word)
 	if !e.started && e.wordIdx == 0 && inputIdx == 0 {
 		if len(currentWord) > 0 && char == string(currentWord[0]) {
@@ -135,17 +279,28 @@ func (e *Engine) ProcessKeystrokeWithTiming(char string, seekTimeMs int64) Keyst
 	e.input += char
 	e.session.TotalCharacters++
 
+	// Track characters retyped after backspacing
+	if inputIdx < e.wordMaxInput {
+		e.session.RecordRetype()
+	} else {
+		e.wordMaxInput = len(e.input)
+	}
+
+	// Every keystroke after the timer starts contributes to the word's duration
+	if e.started && seekTimeMs > 0 {
+		e.wordTimeMs += seekTimeMs
+	}
+
 	// Check if character matches
 	isCorrect := inputIdx < len(currentWord) && e.input[inputIdx] == currentWord[inputIdx]
 	result.IsCorrect = isCorrect
 
 	if isCorrect {
 		e.session.CorrectChars++
-		expectedChar := currentWord[inputIdx]
+		// Only record letter stats for letters, not punctuation; capitals
+		// count towards their lowercase letter
+		expectedChar, isLetter := foldLetter(rune(currentWord[inputIdx]))
 		expectedLetter := string(expectedChar)
-
-		// Only record letter stats for actual letters (a-z), not punctuation
-		isLetter := expectedChar >= 'a' && expectedChar <= 'z'
 		if isLetter {
 			// Create a unique key for this character position
 			posKey := fmt.Sprintf("%d:%d", e.wordIdx, inputIdx)
@@ -157,9 +312,9 @@ func (e *Engine) ProcessKeystrokeWithTiming(char string, seekTimeMs int64) Keyst
 				e.recordedCorrect[posKey] = true
 				e.session.RecordLetterCorrect(expectedLetter)
 
-				finger := stats.GetFinger(rune(expectedChar))
-				hand := stats.GetHand(rune(expectedChar))
-				row := stats.GetRow(rune(expectedChar))
+				finger := stats.GetFinger(expectedChar)
+				hand := stats.GetHand(expectedChar)
+				row := stats.GetRow(expectedChar)
 
 				// Record finger, hand, row correct counts (without timing for accuracy)
 				if finger >= 0 {
@@ -173,14 +328,15 @@ func (e *Engine) ProcessKeystrokeWithTiming(char string, seekTimeMs int64) Keyst
 				}
 			}
 
-			finger := stats.GetFinger(rune(expectedChar))
-			hand := stats.GetHand(rune(expectedChar))
-			row := stats.GetRow(rune(expectedChar))
+			finger := stats.GetFinger(expectedChar)
+			hand := stats.GetHand(expectedChar)
+			row := stats.GetRow(expectedChar)
 
 			// Record seek time for ALL correct keystrokes (even retypes)
 			// This is separate from accuracy - timing is always useful data
-			// Exclude first letter of each word (inputIdx > 0)
-			if e.started && inputIdx > 0 && seekTimeMs > 0 && seekTimeMs < 5000 {
+			// Exclude first letter of each word (inputIdx > 0) and the
+			// first key after a pause
+			if e.started && inputIdx > 0 && !spansPause && seekTimeMs > 0 && seekTimeMs < 5000 {
 				e.session.RecordLetterSeekTime(expectedLetter, seekTimeMs)
 				e.session.RecordSeekTime(seekTimeMs)
 
@@ -191,7 +347,7 @@ func (e *Engine) ProcessKeystrokeWithTiming(char string, seekTimeMs int64) Keyst
 
 					// Check for same-finger bigram
 					lastChar := rune(e.lastLetter[0])
-					if stats.IsSameFingerBigram(lastChar, rune(expectedChar)) {
+					if stats.IsSameFingerBigram(lastChar, expectedChar) {
 						e.session.RecordSFB(seekTimeMs)
 					}
 
@@ -229,9 +385,10 @@ func (e *Engine) ProcessKeystrokeWithTiming(char string, seekTimeMs int64) Keyst
 		e.session.IncorrectChars++
 		// Track error substitution pattern
 		if inputIdx < len(currentWord) {
-			expectedChar := currentWord[inputIdx]
-			typedChar := e.input[inputIdx]
-			if expectedChar >= 'a' && expectedChar <= 'z' && typedChar >= 'a' && typedChar <= 'z' {
+			// A letter typed in the wrong case is not a substitution
+			expectedChar, expectedOK := foldLetter(rune(currentWord[inputIdx]))
+			typedChar, typedOK := foldLetter(rune(e.input[inputIdx]))
+			if expectedOK && typedOK && expectedChar != typedChar {
 				e.session.RecordErrorSubstitution(string(expectedChar), string(typedChar))
 			}
 		}
@@ -244,6 +401,7 @@ func (e *Engine) ProcessKeystrokeWithTiming(char string, seekTimeMs int64) Keyst
 func (e *Engine) ProcessBackspace() bool {
 	if len(e.input) > 0 {
 		e.input = e.input[:len(e.input)-1]
+		e.session.RecordBackspace()
 		return true
 	}
 	return false
@@ -261,13 +419,23 @@ func (e *Engine) ProcessSpaceWithTiming(seekTimeMs int64) SpaceResult {
 	}
 
 	currentWord := e.words[e.wordIdx]
+	e.recordReaction(seekTimeMs, e.paused)
+	e.paused = false
+
+	if e.started && seekTimeMs > 0 {
+		e.wordTimeMs += seekTimeMs
+	}
 
 	// Only advance if all letters have been typed
 	if len(e.input) >= len(currentWord) {
 		e.session.WordsCompleted++
+		e.session.RecordUncorrectedErrors(countMismatches(e.input, currentWord))
+		e.session.RecordWordTime(len(currentWord), e.wordTimeMs)
 		e.input = ""
 		e.wordIdx++
 		e.lastLetter = "" // Reset for new word
+		e.wordTimeMs = 0
+		e.wordMaxInput = 0
 
 		if e.wordIdx >= len(e.words) {
 			// Round complete - don't calculate yet, wait for SubmitTiming
@@ -278,7 +446,13 @@ func (e *Engine) ProcessSpaceWithTiming(seekTimeMs int64) SpaceResult {
 
 	// Treat space as incorrect if word not complete
 	if len(e.input) > 0 || e.started {
+		if len(e.input) < e.wordMaxInput {
+			e.session.RecordRetype()
+		}
 		e.input += " "
+		if len(e.input) > e.wordMaxInput {
+			e.wordMaxInput = len(e.input)
+		}
 		e.session.TotalCharacters++
 		e.session.IncorrectChars++
 		return SpaceResult{TreatedAsError: true}
@@ -302,39 +476,49 @@ func (e *Engine) SubmitTiming(startTime, endTime time.Time, durationMs int64) {
 	if e.session.TotalCharacters > 0 {
 		e.session.Accuracy = (float64(e.session.CorrectChars) / float64(e.session.TotalCharacters)) * 100
 	}
+	e.session.CalculateSpeedBreakdown()
+	e.session.CalculateRhythmSummary()
 
 	// Update historical stats
-	e.historical.UpdateHistorical(e.session)
+	e.history.Record(e.session)
 }
 
 // GetGameState returns a snapshot of the current game state.
 func (e *Engine) GetGameState() GameState {
+	state := NewGameState(e.words, e.wordIdx, e.input, e.started, e.config.PunctuationMode)
+	state.QuoteSource = e.session.QuoteSource
+	return state
+}
+
+// NewGameState builds a game state snapshot, deriving the progress and
+// carousel fields from the round's words and the position within them.
+func NewGameState(words []string, wordIdx int, input string, started, punctuationMode bool) GameState {
 	state := GameState{
-		Words:           e.words,
-		CurrentWordIdx:  e.wordIdx,
-		CurrentInput:    e.input,
-		TimerStarted:    e.started,
-		PunctuationMode: e.config.PunctuationMode,
-		WordNumber:      e.wordIdx + 1,
-		TotalWords:      len(e.words),
+		Words:           words,
+		CurrentWordIdx:  wordIdx,
+		CurrentInput:    input,
+		TimerStarted:    started,
+		PunctuationMode: punctuationMode,
+		WordNumber:      wordIdx + 1,
+		TotalWords:      len(words),
 	}
 
-	if e.wordIdx < len(e.words) {
-		state.CurrentWord = e.words[e.wordIdx]
+	if wordIdx < len(words) {
+		state.CurrentWord = words[wordIdx]
 	}
-	if e.wordIdx > 0 {
-		state.PreviousWord = e.words[e.wordIdx-1]
+	if wordIdx > 0 && wordIdx <= len(words) {
+		state.PreviousWord = words[wordIdx-1]
 	}
-	if e.wordIdx < len(e.words)-1 {
-		state.NextWord = e.words[e.wordIdx+1]
+	if wordIdx < len(words)-1 {
+		state.NextWord = words[wordIdx+1]
 	}
 
 	// Populate next 3 words (or fewer if near the end)
 	state.NextWords = []string{}
 	for i := 1; i <= 3; i++ {
-		nextIdx := e.wordIdx + i
-		if nextIdx < len(e.words) {
-			state.NextWords = append(state.NextWords, e.words[nextIdx])
+		nextIdx := wordIdx + i
+		if nextIdx < len(words) {
+			state.NextWords = append(state.NextWords, words[nextIdx])
 		}
 	}
 
@@ -352,21 +536,51 @@ func (e *Engine) GetSessionStats() *stats.Stats {
 
 // GetHistoricalStats returns the historical statistics.
 func (e *Engine) GetHistoricalStats() *stats.HistoricalStats {
-	return e.historical
+	return e.history.Snapshot()
+}
+
+// GetTrends returns trend analysis over the recorded round history.
+func (e *Engine) GetTrends() stats.TrendReport {
+	return e.history.Snapshot().Trends()
 }
 
 // SaveStats persists the current historical stats to disk.
 func (e *Engine) SaveStats() error {
-	return stats.SaveHistoricalStats(e.historical)
+	return e.history.Save()
+}
+
+// foldLetter returns the lowercase letter for a-z and A-Z, so capitals
+// count towards their letter's stats, and false for anything else.
+func foldLetter(c rune) (rune, bool) {
+	switch {
+	case c >= 'a' && c <= 'z':
+		return c, true
+	case c >= 'A' && c <= 'Z':
+		return c - 'A' + 'a', true
+	}
+	return 0, false
+}
+
+// countMismatches returns the number of typed characters that differ from the
+// target word, including any extra characters typed beyond its end.
+func countMismatches(input, word string) int {
+	count := 0
+	for i := 0; i < len(input); i++ {
+		if i >= len(word) || input[i] != word[i] {
+			count++
+		}
+	}
+	return count
 }
 
 // getLetterData extracts letter frequency and accuracy data for word selection.
 func (e *Engine) getLetterData() words.LetterData {
 	data := make(words.LetterData)
-	if e.historical == nil || e.historical.LetterAccuracy == nil {
+	historical := e.history.Snapshot()
+	if historical.LetterAccuracy == nil {
 		return data
 	}
-	for letter, letterStats := range e.historical.LetterAccuracy {
+	for letter, letterStats := range historical.LetterAccuracy {
 		data[letter] = words.LetterStats{
 			Presented: letterStats.Presented,
 			Correct:   letterStats.Correct,
@@ -0,0 +1,126 @@
+package backend
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Error codes returned in APIError.Code
+const (
+	CodeInvalidRequest  = "INVALID_REQUEST"   // Malformed or invalid request body
+	CodeRequestTooLarge = "REQUEST_TOO_LARGE" // Request body over MaxRequestBytes
+	CodeUnauthorized    = "UNAUTHORIZED"      // Missing or unknown token
+	CodeForbidden       = "FORBIDDEN"         // Token belongs to another profile
+	CodeSessionNotFound = "SESSION_NOT_FOUND" // No session with the given ID
+	CodeInternalError   = "INTERNAL_ERROR"    // Server-side failure
+)
+
+// MaxRequestBytes is the largest request body the server reads. It
+// comfortably fits a full input batch of MaxInputEvents.
+const MaxRequestBytes = 64 << 10
+
+// MaxSeekTimeMs is the longest seek time accepted. Longer gaps between keys
+// are not typing, and suggest a broken clock on the client.
+const MaxSeekTimeMs = 60 * 60 * 1000
+
+// APIError is the body of every error response, and the error returned by
+// clients when the server reports one.
+type APIError struct {
+	Message string `json:"error"` // Human-readable description
+	Code    string `json:"code"`  // Machine-readable error code
+	Status  int    `json:"-"`     // HTTP status
+}
+
+func (e *APIError) Error() string {
+	return e.Message
+}
+
+// errSessionNotFound is returned for every request on an unknown session
+var errSessionNotFound = &APIError{Message: "session not found", Code: CodeSessionNotFound, Status: http.StatusNotFound}
+
+// invalidRequest returns an INVALID_REQUEST error.
+func invalidRequest(format string, args ...any) *APIError {
+	return &APIError{Message: fmt.Sprintf(format, args...), Code: CodeInvalidRequest, Status: http.StatusBadRequest}
+}
+
+// errForbidden returns a FORBIDDEN error.
+func errForbidden(message string) *APIError {
+	return &APIError{Message: message, Code: CodeForbidden, Status: http.StatusForbidden}
+}
+
+// writeError sends err as a JSON error response. Errors other than
+// *APIError are reported as internal errors.
+func writeError(w http.ResponseWriter, err error) {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		apiErr = &APIError{Message: err.Error(), Code: CodeInternalError, Status: http.StatusInternalServerError}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(apiErr.Status)
+	json.NewEncoder(w).Encode(apiErr)
+}
+
+// decodeBody decodes a JSON request body of at most MaxRequestBytes into v.
+// An empty body is accepted if optional is set, leaving v unchanged.
+func decodeBody(w http.ResponseWriter, r *http.Request, v any, optional bool) error {
+	body := http.MaxBytesReader(w, r.Body, MaxRequestBytes)
+	err := json.NewDecoder(body).Decode(v)
+
+	var tooLarge *http.MaxBytesError
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, io.EOF) && optional:
+		return nil
+	case errors.Is(err, io.EOF):
+		return invalidRequest("request body is required")
+	case errors.As(err, &tooLarge):
+		return &APIError{
+			Message: fmt.Sprintf("request body larger than %d bytes", MaxRequestBytes),
+			Code:    CodeRequestTooLarge,
+			Status:  http.StatusRequestEntityTooLarge,
+		}
+	}
+	return invalidRequest("invalid request body: %v", err)
+}
+
+// validateChar checks a typed character is a single printable rune.
+func validateChar(char string) error {
+	r, size := utf8.DecodeRuneInString(char)
+	switch {
+	case char == "":
+		return invalidRequest("char is required")
+	case size != len(char):
+		return invalidRequest("char must be a single character, got %q", char)
+	case r == utf8.RuneError || !unicode.IsPrint(r):
+		return invalidRequest("char must be printable, got %q", char)
+	}
+	return nil
+}
+
+// validateSeekTime checks a frontend-measured seek time; zero means unmeasured.
+func validateSeekTime(ms int64) error {
+	if ms < 0 || ms > MaxSeekTimeMs {
+		return invalidRequest("seek_time_ms must be between 0 and %d, got %d", MaxSeekTimeMs, ms)
+	}
+	return nil
+}
+
+// validateTiming checks the timing of a completed round is consistent.
+func validateTiming(req TimingRequest) error {
+	switch {
+	case req.StartTimeUnixMs <= 0 || req.EndTimeUnixMs <= 0:
+		return invalidRequest("start_time_unix_ms and end_time_unix_ms are required")
+	case req.EndTimeUnixMs < req.StartTimeUnixMs:
+		return invalidRequest("end_time_unix_ms is before start_time_unix_ms")
+	case req.DurationMs < 0:
+		return invalidRequest("duration_ms must not be negative")
+	}
+	return nil
+}
@@ -0,0 +1,191 @@
+package backend
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/timlinux/baboon/stats"
+)
+
+// ProfileHistory is the shared historical stats for one profile.
+// Every engine playing as the profile reads from and records rounds into the
+// same ProfileHistory, so a round completed in one session is immediately
+// visible to all the others.
+//
+// The current HistoricalStats is treated as an immutable snapshot: updates are
+// applied to a copy which then replaces it, so readers can use a snapshot
+// without holding the lock. Saves are serialised separately and do not hold
+// the snapshot lock while writing, so a slow disk never delays other sessions.
+type ProfileHistory struct {
+	profile string
+	store   stats.StatsStore
+
+	saveMu sync.Mutex // Serialises saves
+
+	mu         sync.Mutex
+	historical *stats.HistoricalStats
+	recorded   []*stats.Stats // Sessions recorded since the last save started
+	abandoned  int            // Rounds abandoned without partial stats since then
+}
+
+// LoadProfileHistory loads the historical stats for a profile from the
+// given storage backend.
+func LoadProfileHistory(storage, profile string) (*ProfileHistory, error) {
+	store, err := stats.OpenStore(storage, profile)
+	if err != nil {
+		return nil, err
+	}
+	return newProfileHistory(profile, store)
+}
+
+// newProfileHistory loads a profile's historical stats from a store.
+func newProfileHistory(profile string, store stats.StatsStore) (*ProfileHistory, error) {
+	historical, err := store.Load()
+	if err != nil {
+		return nil, err
+	}
+	return &ProfileHistory{profile: profile, store: store, historical: historical}, nil
+}
+
+// Profile returns the name of the profile.
+func (p *ProfileHistory) Profile() string {
+	return p.profile
+}
+
+// Snapshot returns the current historical stats. The result must not be modified.
+func (p *ProfileHistory) Snapshot() *stats.HistoricalStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.historical
+}
+
+// Record merges a completed session into the shared historical stats.
+func (p *ProfileHistory) Record(session *stats.Stats) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	updated := p.historical.Clone()
+	updated.UpdateHistorical(session)
+	p.historical = updated
+	p.recorded = append(p.recorded, session)
+}
+
+// RecordAbandoned counts a round abandoned without keeping its partial stats.
+func (p *ProfileHistory) RecordAbandoned() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	updated := p.historical.Clone()
+	updated.RecordAbandoned()
+	p.historical = updated
+	p.abandoned++
+}
+
+// Unsaved returns the number of rounds recorded since the last save.
+func (p *ProfileHistory) Unsaved() int {
+	return p.Snapshot().PendingSessions()
+}
+
+// Save persists the shared historical stats, merging with any rounds saved
+// by other processes since it was loaded.
+func (p *ProfileHistory) Save() error {
+	p.saveMu.Lock()
+	defer p.saveMu.Unlock()
+
+	p.mu.Lock()
+	saving := p.historical.Clone()
+	savedCount := len(p.recorded)
+	savedAbandoned := p.abandoned
+	p.mu.Unlock()
+
+	if err := p.store.Save(saving); err != nil {
+		return err
+	}
+
+	// Replay rounds recorded while the file was being written onto the
+	// saved result; they stay pending until the next save
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, session := range p.recorded[savedCount:] {
+		saving.UpdateHistorical(session)
+	}
+	for i := savedAbandoned; i < p.abandoned; i++ {
+		saving.RecordAbandoned()
+	}
+	p.recorded = p.recorded[savedCount:]
+	p.abandoned -= savedAbandoned
+	p.historical = saving
+	return nil
+}
+
+// HistoryStore holds the shared historical stats for every profile in use by
+// a server, loading each profile from disk the first time it is requested.
+type HistoryStore struct {
+	open     func(profile string) (stats.StatsStore, error)
+	mu       sync.Mutex
+	profiles map[string]*ProfileHistory
+}
+
+// NewHistoryStore creates an empty history store using the given storage backend.
+func NewHistoryStore(storage string) *HistoryStore {
+	return newHistoryStoreWith(func(profile string) (stats.StatsStore, error) {
+		return stats.OpenStore(storage, profile)
+	})
+}
+
+// newHistoryStoreWith creates an empty history store that opens profile
+// stores with the given function.
+func newHistoryStoreWith(open func(profile string) (stats.StatsStore, error)) *HistoryStore {
+	return &HistoryStore{open: open, profiles: make(map[string]*ProfileHistory)}
+}
+
+// Get returns the shared history for a profile, loading it if necessary.
+// An empty profile name selects stats.DefaultProfile.
+func (h *HistoryStore) Get(profile string) (*ProfileHistory, error) {
+	if profile == "" {
+		profile = stats.DefaultProfile
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if history, ok := h.profiles[profile]; ok {
+		return history, nil
+	}
+	store, err := h.open(profile)
+	if err != nil {
+		return nil, err
+	}
+	history, err := newProfileHistory(profile, store)
+	if err != nil {
+		return nil, err
+	}
+	h.profiles[profile] = history
+	return history, nil
+}
+
+// SaveAll saves every loaded profile that has unsaved rounds. It returns the
+// number of rounds flushed per profile and an error for each profile that
+// could not be saved.
+func (h *HistoryStore) SaveAll() (map[string]int, error) {
+	h.mu.Lock()
+	histories := make([]*ProfileHistory, 0, len(h.profiles))
+	for _, history := range h.profiles {
+		histories = append(histories, history)
+	}
+	h.mu.Unlock()
+
+	flushed := make(map[string]int)
+	var errs []error
+	for _, history := range histories {
+		unsaved := history.Unsaved()
+		if unsaved == 0 {
+			continue
+		}
+		if err := history.Save(); err != nil {
+			errs = append(errs, fmt.Errorf("profile %s: %w", history.Profile(), err))
+			continue
+		}
+		flushed[history.Profile()] = unsaved
+	}
+	return flushed, errors.Join(errs...)
+}
@@ -0,0 +1,280 @@
+package backend
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/timlinux/baboon/stats"
+)
+
+// HookEventRoundCompleted is the event sent when a round's timing is submitted
+const HookEventRoundCompleted = "round.completed"
+
+// Hook delivery limits
+const (
+	hookQueueSize      = 64               // Rounds waiting for delivery before new ones are dropped
+	webhookAttempts    = 4                // One try and three retries
+	webhookTimeout     = 10 * time.Second // Per attempt
+	commandTimeout     = 30 * time.Second
+	webhookRetryDelay  = time.Second // Doubled after each failed attempt
+	hookOutputLogLimit = 1024        // Bytes of a failed command's output to log
+)
+
+// HookConfig selects what is notified when a round completes. Either, both
+// or neither hook may be set.
+type HookConfig struct {
+	// WebhookURL receives each RoundCompletedEvent as a JSON POST.
+	WebhookURL string
+
+	// WebhookSecret, if set, signs each body with HMAC-SHA256 in the
+	// X-Baboon-Signature header, so the receiver can check its origin.
+	WebhookSecret string
+
+	// Command is run with each RoundCompletedEvent as JSON on its stdin. The
+	// first element is the program; it is not run through a shell.
+	Command []string
+}
+
+// Enabled reports whether any hook is configured.
+func (c HookConfig) Enabled() bool {
+	return c.WebhookURL != "" || len(c.Command) > 0
+}
+
+// RoundCompletedEvent is the JSON document sent to hooks when a round completes.
+type RoundCompletedEvent struct {
+	Event       string    `json:"event"` // HookEventRoundCompleted
+	SessionID   string    `json:"session_id"`
+	Profile     string    `json:"profile"`
+	CompletedAt time.Time `json:"completed_at"`
+
+	WPM            float64 `json:"wpm"`
+	RawWPM         float64 `json:"raw_wpm"`
+	NetWPM         float64 `json:"net_wpm"`
+	Accuracy       float64 `json:"accuracy"`
+	DurationMs     int64   `json:"duration_ms"`
+	WordsCompleted int     `json:"words_completed"`
+	CorrectChars   int     `json:"correct_chars"`
+	IncorrectChars int     `json:"incorrect_chars"`
+}
+
+// NewRoundCompletedEvent builds the event sent for a completed round.
+func NewRoundCompletedEvent(sessionID, profile string, round *stats.Stats) RoundCompletedEvent {
+	completedAt := round.EndTime
+	if completedAt.IsZero() {
+		completedAt = time.Now()
+	}
+	return RoundCompletedEvent{
+		Event:          HookEventRoundCompleted,
+		SessionID:      sessionID,
+		Profile:        profile,
+		CompletedAt:    completedAt.UTC(),
+		WPM:            round.WPM,
+		RawWPM:         round.RawWPM,
+		NetWPM:         round.NetWPM,
+		Accuracy:       round.Accuracy,
+		DurationMs:     round.Duration.Milliseconds(),
+		WordsCompleted: round.WordsCompleted,
+		CorrectChars:   round.CorrectChars,
+		IncorrectChars: round.IncorrectChars,
+	}
+}
+
+// Hooks delivers round summaries to the configured webhook and command.
+// Rounds are queued and delivered one at a time in the background, so a
+// slow receiver never delays the request that completed the round.
+type Hooks struct {
+	config     HookConfig
+	client     *http.Client
+	logger     *slog.Logger
+	retryDelay time.Duration
+
+	start   sync.Once
+	mu      sync.Mutex // Guards closing the queue
+	closed  bool
+	queue   chan RoundCompletedEvent
+	drained chan struct{}
+}
+
+// NewHooks creates a hook runner for config, logging deliveries to logger.
+func NewHooks(config HookConfig, logger *slog.Logger) *Hooks {
+	return &Hooks{
+		config:     config,
+		client:     &http.Client{Timeout: webhookTimeout},
+		logger:     logger,
+		retryDelay: webhookRetryDelay,
+		queue:      make(chan RoundCompletedEvent, hookQueueSize),
+		drained:    make(chan struct{}),
+	}
+}
+
+// Deliver runs every configured hook for event, waiting for them to
+// finish. The webhook is retried on network errors and 429 or 5xx
+// responses.
+func (h *Hooks) Deliver(ctx context.Context, event RoundCompletedEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	if h.config.WebhookURL != "" {
+		if err := h.postWebhook(ctx, event.Event, body); err != nil {
+			errs = append(errs, fmt.Errorf("webhook: %w", err))
+		}
+	}
+	if len(h.config.Command) > 0 {
+		if err := h.runCommand(ctx, event.Event, body); err != nil {
+			errs = append(errs, fmt.Errorf("command: %w", err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Signature returns the X-Baboon-Signature header value for a body signed
+// with secret.
+func Signature(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func (h *Hooks) postWebhook(ctx context.Context, event string, body []byte) error {
+	// The delivery ID stays the same across retries so receivers can
+	// discard duplicates
+	delivery := generateSessionID()
+	delay := h.retryDelay
+
+	var err error
+	for attempt := 1; attempt <= webhookAttempts; attempt++ {
+		var retry bool
+		if retry, err = h.postOnce(ctx, event, delivery, body); err == nil || !retry {
+			return err
+		}
+		if attempt == webhookAttempts {
+			break
+		}
+		h.logger.Warn("webhook failed, retrying", "error", err, "attempt", attempt, "delay", delay)
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return errors.Join(err, ctx.Err())
+		}
+		delay *= 2
+	}
+	return fmt.Errorf("giving up after %d attempts: %w", webhookAttempts, err)
+}
+
+// postOnce makes one webhook attempt, reporting whether a failure is worth retrying.
+func (h *Hooks) postOnce(ctx context.Context, event, delivery string, body []byte) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, "POST", h.config.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "baboon")
+	req.Header.Set("X-Baboon-Event", event)
+	req.Header.Set("X-Baboon-Delivery", delivery)
+	if h.config.WebhookSecret != "" {
+		req.Header.Set("X-Baboon-Signature", Signature(h.config.WebhookSecret, body))
+	}
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return ctx.Err() == nil, err
+	}
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+	resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return false, nil
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return true, fmt.Errorf("status %s", resp.Status)
+	}
+	return false, fmt.Errorf("status %s", resp.Status)
+}
+
+func (h *Hooks) runCommand(ctx context.Context, event string, body []byte) error {
+	ctx, cancel := context.WithTimeout(ctx, commandTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, h.config.Command[0], h.config.Command[1:]...)
+	cmd.Stdin = bytes.NewReader(body)
+	cmd.Env = append(os.Environ(), "BABOON_EVENT="+event)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		if out := strings.TrimSpace(string(output)); out != "" {
+			if len(out) > hookOutputLogLimit {
+				out = out[:hookOutputLogLimit] + "..."
+			}
+			return fmt.Errorf("%w: %s", err, out)
+		}
+		return err
+	}
+	return nil
+}
+
+// enqueue queues an event for background delivery. If the queue is full
+// the event is dropped rather than blocking the caller.
+func (h *Hooks) enqueue(event RoundCompletedEvent) {
+	h.start.Do(func() { go h.run() })
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		return
+	}
+	select {
+	case h.queue <- event:
+	default:
+		h.logger.Error("hook queue full, round not delivered",
+			"session", event.SessionID, "profile", event.Profile)
+	}
+}
+
+// run delivers queued summaries until the queue is closed.
+func (h *Hooks) run() {
+	defer close(h.drained)
+	for event := range h.queue {
+		logger := h.logger.With("session", event.SessionID, "profile", event.Profile)
+		if err := h.Deliver(context.Background(), event); err != nil {
+			logger.Error("round hook failed", "error", err)
+		} else {
+			logger.Debug("round hook delivered")
+		}
+	}
+}
+
+// close stops accepting summaries and waits for queued ones to be
+// delivered, or for ctx to expire.
+func (h *Hooks) close(ctx context.Context) error {
+	h.start.Do(func() { go h.run() })
+
+	h.mu.Lock()
+	if !h.closed {
+		h.closed = true
+		close(h.queue)
+	}
+	h.mu.Unlock()
+
+	select {
+	case <-h.drained:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("round hooks not delivered: %w", ctx.Err())
+	}
+}
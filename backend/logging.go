@@ -0,0 +1,101 @@
+package backend
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// Log output formats
+const (
+	LogFormatText = "text"
+	LogFormatJSON = "json"
+)
+
+// NewLogger creates a structured logger writing to w at the given level
+// (debug, info, warn or error) in text or JSON format.
+func NewLogger(w io.Writer, level, format string) (*slog.Logger, error) {
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(level)); err != nil {
+		return nil, fmt.Errorf("invalid log level %q (use debug, info, warn or error)", level)
+	}
+	opts := &slog.HandlerOptions{Level: lvl}
+
+	switch format {
+	case LogFormatText:
+		return slog.New(slog.NewTextHandler(w, opts)), nil
+	case LogFormatJSON:
+		return slog.New(slog.NewJSONHandler(w, opts)), nil
+	}
+	return nil, fmt.Errorf("invalid log format %q (use %s or %s)", format, LogFormatText, LogFormatJSON)
+}
+
+// SetLogger sets the logger for access logs, game events and hook
+// deliveries. A new server discards its logs, as the combined mode's TUI
+// owns the terminal. It must be called before Start.
+func (s *Server) SetLogger(logger *slog.Logger) {
+	s.logger = logger
+	if s.hooks != nil {
+		s.hooks.logger = logger
+	}
+}
+
+// logRequest writes the access log entry for a handled request. Server
+// errors are logged at error level, everything else at info.
+func (s *Server) logRequest(r route, req *http.Request, status int, elapsed time.Duration) {
+	level := slog.LevelInfo
+	if status >= http.StatusInternalServerError {
+		level = slog.LevelError
+	}
+	attrs := []slog.Attr{
+		slog.String("method", req.Method),
+		slog.String("route", r.path),
+		slog.String("path", req.URL.Path),
+		slog.Int("status", status),
+		slog.Duration("duration", elapsed),
+		slog.String("remote", req.RemoteAddr),
+	}
+	if id := req.PathValue("id"); id != "" {
+		attrs = append(attrs, slog.String("session", id))
+	}
+	s.logger.LogAttrs(req.Context(), level, "request", attrs...)
+}
+
+// sessionLogger returns a logger that tags entries with a session and its profile.
+func (s *Server) sessionLogger(session *Session) *slog.Logger {
+	return s.logger.With("session", session.ID, "profile", session.Profile)
+}
+
+// roundStarted records a new round in a session.
+func (s *Server) roundStarted(session *Session) {
+	s.sessionLogger(session).Info("round started")
+}
+
+// roundCompleted records a round whose timing has been submitted and queues
+// it for the round hooks. It must be called with session.mu held.
+func (s *Server) roundCompleted(session *Session) {
+	result := session.Engine.GetSessionStats()
+	s.metrics.roundCompleted(result.WPM)
+	if s.hooks != nil {
+		s.hooks.enqueue(NewRoundCompletedEvent(session.ID, session.Profile, result))
+	}
+	s.sessionLogger(session).Info("round completed",
+		"wpm", result.WPM,
+		"accuracy", result.Accuracy,
+		"words", result.WordsCompleted,
+		"duration", result.Duration)
+}
+
+// roundAbandoned records a round given up part way through.
+func (s *Server) roundAbandoned(session *Session, keepPartial bool) {
+	s.metrics.roundAbandoned()
+	s.sessionLogger(session).Info("round abandoned", "keep_partial", keepPartial)
+}
+
+// saveFailed records a failed attempt to save a session's profile stats.
+func (s *Server) saveFailed(session *Session, err error) {
+	s.metrics.saveFailed()
+	s.sessionLogger(session).Error("save failed", "error", err)
+}
@@ -0,0 +1,295 @@
+package backend
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Histogram bucket upper bounds
+var (
+	latencyBuckets = []float64{0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+	wpmBuckets     = []float64{10, 20, 30, 40, 50, 60, 70, 80, 90, 100, 120, 140, 160, 200}
+)
+
+// metrics collects the counters exposed at /metrics. Gauges such as the
+// number of active sessions are read from the server when scraped instead.
+type metrics struct {
+	mu           sync.Mutex
+	requests     map[requestKey]uint64   // By route and status
+	latency      map[routeKey]*histogram // By route
+	keystrokes   map[string]uint64       // By input event type: keystroke, backspace or space
+	roundEvents  map[string]uint64       // By input event type: pause or start
+	rounds       uint64                  // Rounds completed
+	abandoned    uint64                  // Rounds abandoned
+	wpm          *histogram              // WPM of completed rounds
+	saveFailures uint64                  // Failed stats saves
+}
+
+type routeKey struct{ method, path string }
+
+type requestKey struct {
+	routeKey
+	status int
+}
+
+func newMetrics() *metrics {
+	return &metrics{
+		requests:    make(map[requestKey]uint64),
+		latency:     make(map[routeKey]*histogram),
+		keystrokes:  make(map[string]uint64),
+		roundEvents: make(map[string]uint64),
+		wpm:         newHistogram(wpmBuckets),
+	}
+}
+
+// histogram counts observations into cumulative buckets, as Prometheus
+// histograms do. It is guarded by the metrics mutex.
+type histogram struct {
+	bounds []float64
+	counts []uint64 // counts[i] observations <= bounds[i]
+	sum    float64
+	count  uint64
+}
+
+func newHistogram(bounds []float64) *histogram {
+	return &histogram{bounds: bounds, counts: make([]uint64, len(bounds))}
+}
+
+func (h *histogram) observe(v float64) {
+	for i, bound := range h.bounds {
+		if v <= bound {
+			h.counts[i]++
+		}
+	}
+	h.sum += v
+	h.count++
+}
+
+// observeRequest records a handled request. Hijacked requests, such as
+// WebSocket upgrades, are counted but their duration is not: it is the
+// lifetime of the connection rather than the time to respond.
+func (m *metrics) observeRequest(r route, status int, elapsed time.Duration, hijacked bool) {
+	key := routeKey{r.method, r.path}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.requests[requestKey{key, status}]++
+	if hijacked {
+		return
+	}
+	h, ok := m.latency[key]
+	if !ok {
+		h = newHistogram(latencyBuckets)
+		m.latency[key] = h
+	}
+	h.observe(elapsed.Seconds())
+}
+
+// countInput records processed input events. Pauses and countdown starts
+// are not keys typed into the round, so they are counted separately.
+func (m *metrics) countInput(events []InputEvent) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, event := range events {
+		switch event.Type {
+		case InputPause, InputStart:
+			m.roundEvents[event.Type]++
+		default:
+			m.keystrokes[event.Type]++
+		}
+	}
+}
+
+// roundCompleted records a completed round and its WPM.
+func (m *metrics) roundCompleted(wpm float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.rounds++
+	m.wpm.observe(wpm)
+}
+
+// roundAbandoned records an abandoned round.
+func (m *metrics) roundAbandoned() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.abandoned++
+}
+
+// saveFailed records a failed attempt to save stats.
+func (m *metrics) saveFailed() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.saveFailures++
+}
+
+// instrument wraps a route's handler to record its requests in the metrics
+// and the access log.
+func (s *Server) instrument(r route, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next(rec, req)
+		elapsed := time.Since(start)
+		s.metrics.observeRequest(r, rec.status, elapsed, rec.hijacked)
+		s.logRequest(r, req, rec.status, elapsed)
+	}
+}
+
+// statusRecorder captures the status written by a handler.
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	hijacked    bool
+}
+
+func (w *statusRecorder) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.status = status
+		w.wroteHeader = true
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *statusRecorder) Write(data []byte) (int, error) {
+	w.wroteHeader = true
+	return w.ResponseWriter.Write(data)
+}
+
+// Hijack lets WebSocket upgrades take over the connection, which is
+// recorded as 101 Switching Protocols.
+func (w *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("response does not support hijacking")
+	}
+	conn, rw, err := hijacker.Hijack()
+	if err == nil {
+		w.status = http.StatusSwitchingProtocols
+		w.hijacked = true
+	}
+	return conn, rw, err
+}
+
+// Unwrap gives http.ResponseController access to the underlying writer.
+func (w *statusRecorder) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	s.mu.RLock()
+	sessions := len(s.sessions)
+	s.mu.RUnlock()
+	s.wsMu.Lock()
+	connections := len(s.wsConns)
+	s.wsMu.Unlock()
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	s.metrics.write(w, sessions, connections)
+}
+
+// write renders the metrics in the Prometheus text exposition format.
+// Series are sorted so that the output is stable between scrapes.
+func (m *metrics) write(out io.Writer, sessions, connections int) {
+	w := bufio.NewWriter(out)
+	defer w.Flush()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	header(w, "baboon_active_sessions", "gauge", "Game sessions currently open.")
+	fmt.Fprintf(w, "baboon_active_sessions %d\n", sessions)
+
+	header(w, "baboon_websocket_connections", "gauge", "WebSocket connections currently open.")
+	fmt.Fprintf(w, "baboon_websocket_connections %d\n", connections)
+
+	header(w, "baboon_http_requests_total", "counter", "HTTP requests handled, by route and status code.")
+	requests := make([]requestKey, 0, len(m.requests))
+	for key := range m.requests {
+		requests = append(requests, key)
+	}
+	sort.Slice(requests, func(i, j int) bool {
+		if requests[i].routeKey != requests[j].routeKey {
+			return requests[i].routeKey.less(requests[j].routeKey)
+		}
+		return requests[i].status < requests[j].status
+	})
+	for _, key := range requests {
+		fmt.Fprintf(w, "baboon_http_requests_total{%s,code=\"%d\"} %d\n", key.labels(), key.status, m.requests[key])
+	}
+
+	header(w, "baboon_http_request_duration_seconds", "histogram", "Time to handle HTTP requests, by route.")
+	routes := make([]routeKey, 0, len(m.latency))
+	for key := range m.latency {
+		routes = append(routes, key)
+	}
+	sort.Slice(routes, func(i, j int) bool { return routes[i].less(routes[j]) })
+	for _, key := range routes {
+		m.latency[key].write(w, "baboon_http_request_duration_seconds", key.labels())
+	}
+
+	header(w, "baboon_keystrokes_total", "counter", "Keys typed into rounds, by type.")
+	for _, eventType := range []string{InputKeystroke, InputBackspace, InputSpace} {
+		fmt.Fprintf(w, "baboon_keystrokes_total{type=%q} %d\n", eventType, m.keystrokes[eventType])
+	}
+
+	header(w, "baboon_round_events_total", "counter", "Pauses and countdown starts, by type.")
+	for _, eventType := range []string{InputPause, InputStart} {
+		fmt.Fprintf(w, "baboon_round_events_total{type=%q} %d\n", eventType, m.roundEvents[eventType])
+	}
+
+	header(w, "baboon_rounds_completed_total", "counter", "Rounds completed and recorded.")
+	fmt.Fprintf(w, "baboon_rounds_completed_total %d\n", m.rounds)
+
+	header(w, "baboon_rounds_abandoned_total", "counter", "Rounds abandoned part way through.")
+	fmt.Fprintf(w, "baboon_rounds_abandoned_total %d\n", m.abandoned)
+
+	header(w, "baboon_round_wpm", "histogram", "Words per minute of completed rounds.")
+	m.wpm.write(w, "baboon_round_wpm", "")
+
+	header(w, "baboon_save_failures_total", "counter", "Failed attempts to save statistics.")
+	fmt.Fprintf(w, "baboon_save_failures_total %d\n", m.saveFailures)
+}
+
+// header writes a metric's HELP and TYPE lines.
+func header(w io.Writer, name, kind, help string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+// write renders a histogram's buckets, sum and count, adding labels, if
+// any, to each series.
+func (h *histogram) write(w io.Writer, name, labels string) {
+	prefix := ""
+	if labels != "" {
+		prefix = labels + ","
+	}
+	for i, bound := range h.bounds {
+		le := strconv.FormatFloat(bound, 'g', -1, 64)
+		fmt.Fprintf(w, "%s_bucket{%sle=%q} %d\n", name, prefix, le, h.counts[i])
+	}
+	fmt.Fprintf(w, "%s_bucket{%sle=\"+Inf\"} %d\n", name, prefix, h.count)
+	if labels != "" {
+		labels = "{" + labels + "}"
+	}
+	fmt.Fprintf(w, "%s_sum%s %s\n", name, labels, strconv.FormatFloat(h.sum, 'g', -1, 64))
+	fmt.Fprintf(w, "%s_count%s %d\n", name, labels, h.count)
+}
+
+func (k routeKey) less(other routeKey) bool {
+	if k.path != other.path {
+		return k.path < other.path
+	}
+	return k.method < other.method
+}
+
+// labels returns the route's label pairs. Routes are patterns from the route
+// table, so they need no escaping beyond quoting.
+func (k routeKey) labels() string {
+	return fmt.Sprintf("method=%q,route=%q", k.method, k.path)
+}
@@ -0,0 +1,290 @@
+package backend
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// route describes one API endpoint for both the mux and the OpenAPI document.
+type route struct {
+	method   string
+	path     string
+	summary  string
+	handler  http.HandlerFunc
+	status   int // Success status; zero means 200 OK
+	request  any // Example request body, or nil if the endpoint takes none
+	response any // Example response body, or nil if the endpoint returns none
+
+	optionalBody bool // The request body may be omitted; defaults are used
+	public       bool // No bearer token is required
+	plainText    bool // The response is text/plain rather than JSON
+}
+
+// successStatus returns the status the route responds with on success.
+func (r route) successStatus() int {
+	if r.status == 0 {
+		return http.StatusOK
+	}
+	return r.status
+}
+
+// OpenAPI returns the OpenAPI 3 document describing the REST API. Request
+// and response schemas are derived from the Go types the handlers encode, so
+// the document changes whenever they do.
+func (s *Server) OpenAPI() map[string]any {
+	components := newSchemaSet()
+	paths := map[string]any{}
+	errorResponse := func(description string) map[string]any {
+		return map[string]any{
+			"description": description,
+			"content":     jsonContent(components.schema(reflect.TypeOf(APIError{})), nil),
+		}
+	}
+
+	for _, r := range s.routeTable() {
+		op := map[string]any{
+			"summary":     r.summary,
+			"operationId": operationID(r),
+			"responses":   map[string]any{},
+		}
+		responses := op["responses"].(map[string]any)
+
+		success := map[string]any{"description": http.StatusText(r.successStatus())}
+		if r.response != nil {
+			success["content"] = jsonContent(components.schema(reflect.TypeOf(r.response)), nil)
+		} else if r.plainText {
+			success["content"] = map[string]any{"text/plain": map[string]any{"schema": map[string]any{"type": "string"}}}
+		}
+		responses[strconv.Itoa(r.successStatus())] = success
+
+		if r.request != nil {
+			op["requestBody"] = map[string]any{
+				"required": !r.optionalBody,
+				"content":  jsonContent(components.schema(reflect.TypeOf(r.request)), r.request),
+			}
+			responses[strconv.Itoa(http.StatusBadRequest)] = errorResponse("Malformed or invalid request body (INVALID_REQUEST)")
+			responses[strconv.Itoa(http.StatusRequestEntityTooLarge)] = errorResponse("Request body too large (REQUEST_TOO_LARGE)")
+		}
+		if strings.Contains(r.path, "{id}") {
+			op["parameters"] = []any{map[string]any{
+				"name":        "id",
+				"in":          "path",
+				"required":    true,
+				"description": "Session ID returned by POST /api/sessions",
+				"schema":      map[string]any{"type": "string"},
+			}}
+			responses[strconv.Itoa(http.StatusNotFound)] = errorResponse("Session not found (SESSION_NOT_FOUND)")
+		}
+		if r.public {
+			op["security"] = []any{}
+		} else {
+			responses[strconv.Itoa(http.StatusUnauthorized)] = errorResponse("Missing or invalid bearer token (UNAUTHORIZED)")
+			if strings.Contains(r.path, "{id}") || r.path == "/api/sessions" && r.method == "POST" {
+				responses[strconv.Itoa(http.StatusForbidden)] = errorResponse("The token belongs to another profile (FORBIDDEN)")
+			}
+		}
+
+		item, ok := paths[r.path].(map[string]any)
+		if !ok {
+			item = map[string]any{}
+			paths[r.path] = item
+		}
+		item[strings.ToLower(r.method)] = op
+	}
+
+	scheme := "http://"
+	if s.usesTLS() {
+		scheme = "https://"
+	}
+
+	return map[string]any{
+		"openapi": "3.0.3",
+		"info": map[string]any{
+			"title":       "Baboon API",
+			"description": "Game sessions and statistics for the Baboon typing trainer.",
+			"version":     "1",
+		},
+		"servers":  []any{map[string]any{"url": scheme + s.addr}},
+		"security": []any{map[string]any{"bearerAuth": []any{}}},
+		"paths":    paths,
+		"components": map[string]any{
+			"schemas": components.schemas,
+			"securitySchemes": map[string]any{
+				"bearerAuth": map[string]any{
+					"type":        "http",
+					"scheme":      "bearer",
+					"description": "Per-profile token from ~/.config/baboon/tokens.json (see baboon token)",
+				},
+			},
+		},
+	}
+}
+
+// OpenAPI returns the OpenAPI document for a server listening on addr.
+func OpenAPI(addr string) map[string]any {
+	return (&Server{addr: addr}).OpenAPI()
+}
+
+func (s *Server) handleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(s.OpenAPI())
+}
+
+// operationID derives a stable operation name from a route, for example
+// "post_sessions_id_keystroke".
+func operationID(r route) string {
+	path := strings.TrimPrefix(r.path, "/api/")
+	path = strings.NewReplacer("{", "", "}", "", "/", "_", ".", "_").Replace(path)
+	return strings.ToLower(r.method) + "_" + path
+}
+
+// jsonContent describes an application/json body with an optional example.
+func jsonContent(schema map[string]any, example any) map[string]any {
+	media := map[string]any{"schema": schema}
+	if example != nil {
+		media["example"] = example
+	}
+	return map[string]any{"application/json": media}
+}
+
+// schemaSet builds JSON schemas for Go types, collecting named structs as
+// reusable components.
+type schemaSet struct {
+	schemas map[string]any
+	types   map[string]reflect.Type
+}
+
+func newSchemaSet() *schemaSet {
+	return &schemaSet{schemas: map[string]any{}, types: map[string]reflect.Type{}}
+}
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	rawMessageType = reflect.TypeOf(json.RawMessage(nil))
+)
+
+// schema returns the schema for t, following encoding/json's rules.
+func (s *schemaSet) schema(t reflect.Type) map[string]any {
+	switch t {
+	case timeType:
+		return map[string]any{"type": "string", "format": "date-time"}
+	case rawMessageType:
+		return map[string]any{}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		format := "int64"
+		if t.Bits() <= 32 {
+			format = "int32"
+		}
+		return map[string]any{"type": "integer", "format": format}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Pointer:
+		return nullable(s.schema(t.Elem()))
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]any{"type": "string", "format": "byte"}
+		}
+		schema := map[string]any{"type": "array", "items": s.schema(t.Elem())}
+		if t.Kind() == reflect.Slice {
+			schema = nullable(schema)
+		}
+		return schema
+	case reflect.Map:
+		return nullable(map[string]any{"type": "object", "additionalProperties": s.schema(t.Elem())})
+	case reflect.Struct:
+		return s.component(t)
+	}
+	// Interfaces and anything else may hold any value
+	return map[string]any{}
+}
+
+// component registers a struct schema and returns a reference to it.
+func (s *schemaSet) component(t reflect.Type) map[string]any {
+	if t.Name() == "" {
+		return s.structSchema(t)
+	}
+
+	name := t.Name()
+	if existing, ok := s.types[name]; ok && existing != t {
+		name = pathBase(t.PkgPath()) + "." + name
+	}
+	ref := map[string]any{"$ref": "#/components/schemas/" + name}
+	if _, ok := s.types[name]; ok {
+		return ref
+	}
+	s.types[name] = t
+	s.schemas[name] = map[string]any{} // Placeholder while recursing
+	s.schemas[name] = s.structSchema(t)
+	return ref
+}
+
+// structSchema describes a struct's JSON fields. Fields without omitempty
+// are always present and so are listed as required.
+func (s *schemaSet) structSchema(t reflect.Type) map[string]any {
+	properties := map[string]any{}
+	var required []string
+	s.addFields(t, properties, &required)
+
+	schema := map[string]any{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+func (s *schemaSet) addFields(t reflect.Type, properties map[string]any, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+
+		// Untagged embedded structs are flattened into the parent
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			s.addFields(field.Type, properties, required)
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		properties[name] = s.schema(field.Type)
+		if !strings.Contains(opts, "omitempty") {
+			*required = append(*required, name)
+		}
+	}
+}
+
+// nullable marks a schema as also accepting null, as nil pointers, slices
+// and maps encode to null.
+func nullable(schema map[string]any) map[string]any {
+	if _, isRef := schema["$ref"]; isRef {
+		return map[string]any{"allOf": []any{schema}, "nullable": true}
+	}
+	schema["nullable"] = true
+	return schema
+}
+
+// pathBase returns the last element of a package path.
+func pathBase(pkgPath string) string {
+	return pkgPath[strings.LastIndex(pkgPath, "/")+1:]
+}
@@ -0,0 +1,232 @@
+package backend
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/timlinux/baboon/stats"
+)
+
+// Session operations
+//
+// Each operation of the session API is implemented once here, taking the
+// session ID and the decoded request and returning the response body or an
+// error (an *APIError for client errors). The REST handlers and the
+// WebSocket dispatcher only decode requests and encode the results.
+
+// lookupSession returns the session with the given ID, or errSessionNotFound.
+func (s *Server) lookupSession(id string) (*Session, error) {
+	session, exists := s.getSession(id)
+	if !exists {
+		return nil, errSessionNotFound
+	}
+	return session, nil
+}
+
+// startRound starts a new round with fresh words.
+func (s *Server) startRound(sessionID string) (StatusResponse, error) {
+	session, err := s.lookupSession(sessionID)
+	if err != nil {
+		return StatusResponse{}, err
+	}
+
+	session.mu.Lock()
+	session.Engine.StartRound()
+	session.mu.Unlock()
+	s.roundStarted(session)
+	return StatusResponse{Status: "ok"}, nil
+}
+
+// abandonRound gives up the round in progress.
+func (s *Server) abandonRound(sessionID string, req AbandonRequest) (AbandonResponse, error) {
+	session, err := s.lookupSession(sessionID)
+	if err != nil {
+		return AbandonResponse{}, err
+	}
+
+	session.mu.Lock()
+	abandoned := session.Engine.AbandonRound(req.KeepPartial)
+	session.mu.Unlock()
+	if abandoned {
+		s.roundAbandoned(session, req.KeepPartial)
+	}
+	return AbandonResponse{Abandoned: abandoned}, nil
+}
+
+// processKeystroke applies one typed character.
+func (s *Server) processKeystroke(sessionID string, req KeystrokeRequest) (KeystrokeResponse, error) {
+	session, err := s.lookupSession(sessionID)
+	if err != nil {
+		return KeystrokeResponse{}, err
+	}
+	if err := req.validate(); err != nil {
+		return KeystrokeResponse{}, err
+	}
+
+	session.mu.Lock()
+	// Use timing-aware method with frontend-provided seek time
+	result := session.Engine.ProcessKeystrokeWithTiming(req.Char, req.SeekTimeMs)
+	session.mu.Unlock()
+	s.metrics.countInput([]InputEvent{{Type: InputKeystroke}})
+
+	return KeystrokeResponse{
+		IsCorrect:    result.IsCorrect,
+		TimerStarted: result.TimerStarted,
+		CharIndex:    result.CharIndex,
+	}, nil
+}
+
+// processBackspace removes the last typed character.
+func (s *Server) processBackspace(sessionID string) (BackspaceResponse, error) {
+	session, err := s.lookupSession(sessionID)
+	if err != nil {
+		return BackspaceResponse{}, err
+	}
+
+	session.mu.Lock()
+	removed := session.Engine.ProcessBackspace()
+	session.mu.Unlock()
+	s.metrics.countInput([]InputEvent{{Type: InputBackspace}})
+
+	return BackspaceResponse{Removed: removed}, nil
+}
+
+// processSpace applies the advance key.
+func (s *Server) processSpace(sessionID string, req SpaceRequest) (SpaceResponse, error) {
+	session, err := s.lookupSession(sessionID)
+	if err != nil {
+		return SpaceResponse{}, err
+	}
+	if err := validateSeekTime(req.SeekTimeMs); err != nil {
+		return SpaceResponse{}, err
+	}
+
+	session.mu.Lock()
+	// Use timing-aware method with frontend-provided seek time
+	result := session.Engine.ProcessSpaceWithTiming(req.SeekTimeMs)
+	session.mu.Unlock()
+	s.metrics.countInput([]InputEvent{{Type: InputSpace}})
+
+	return SpaceResponse{
+		Advanced:       result.Advanced,
+		RoundComplete:  result.RoundComplete,
+		TreatedAsError: result.TreatedAsError,
+	}, nil
+}
+
+// processInput applies an ordered batch of input events.
+func (s *Server) processInput(sessionID string, req InputRequest) (InputResponse, error) {
+	session, err := s.lookupSession(sessionID)
+	if err != nil {
+		return InputResponse{}, err
+	}
+	if err := validateInput(req.Events); err != nil {
+		return InputResponse{}, err
+	}
+
+	resp := applyInput(session, req.Events)
+	s.metrics.countInput(req.Events)
+	return resp, nil
+}
+
+// submitTiming completes the round with the client's timing.
+func (s *Server) submitTiming(sessionID string, req TimingRequest) (StatusResponse, error) {
+	session, err := s.lookupSession(sessionID)
+	if err != nil {
+		return StatusResponse{}, err
+	}
+	if err := validateTiming(req); err != nil {
+		return StatusResponse{}, err
+	}
+
+	// Convert Unix milliseconds to time.Time
+	startTime := time.UnixMilli(req.StartTimeUnixMs)
+	endTime := time.UnixMilli(req.EndTimeUnixMs)
+
+	session.mu.Lock()
+	session.Engine.SubmitTiming(startTime, endTime, req.DurationMs)
+	s.roundCompleted(session)
+	session.mu.Unlock()
+	return StatusResponse{Status: "ok"}, nil
+}
+
+// gameState returns the session's game state.
+func (s *Server) gameState(sessionID string) (GameStateResponse, error) {
+	session, err := s.lookupSession(sessionID)
+	if err != nil {
+		return GameStateResponse{}, err
+	}
+
+	session.mu.Lock()
+	state := session.Engine.GetGameState()
+	session.mu.Unlock()
+	return newGameStateResponse(state), nil
+}
+
+// sessionStats returns the current round's statistics, already serialised.
+func (s *Server) sessionStats(sessionID string) (json.RawMessage, error) {
+	session, err := s.lookupSession(sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Serialise while locked: the stats are updated in place by later keystrokes
+	session.mu.Lock()
+	data, err := json.Marshal(session.Engine.GetSessionStats())
+	session.mu.Unlock()
+	return data, err
+}
+
+// historicalStats returns the profile's historical statistics.
+func (s *Server) historicalStats(sessionID string) (*stats.HistoricalStats, error) {
+	session, err := s.lookupSession(sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	session.mu.Lock()
+	historical := session.Engine.GetHistoricalStats()
+	session.mu.Unlock()
+	return historical, nil
+}
+
+// trends returns the profile's trend report.
+func (s *Server) trends(sessionID string) (stats.TrendReport, error) {
+	session, err := s.lookupSession(sessionID)
+	if err != nil {
+		return stats.TrendReport{}, err
+	}
+
+	session.mu.Lock()
+	trends := session.Engine.GetTrends()
+	session.mu.Unlock()
+	return trends, nil
+}
+
+// saveStats writes the profile's historical statistics to disk.
+func (s *Server) saveStats(sessionID string) (StatusResponse, error) {
+	session, err := s.lookupSession(sessionID)
+	if err != nil {
+		return StatusResponse{}, err
+	}
+
+	// Saving only touches the shared profile history, which has its own lock,
+	// so the session stays responsive while the file is written
+	if err := session.Engine.SaveStats(); err != nil {
+		s.saveFailed(session, err)
+		return StatusResponse{}, err
+	}
+	return StatusResponse{Status: "ok"}, nil
+}
+
+// writeResult writes an operation's result as the JSON response, or its
+// error.
+func writeResult(w http.ResponseWriter, result any, err error) {
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
@@ -1,42 +1,78 @@
 package backend
 
 import (
+	"context"
 	"crypto/rand"
 	"encoding/hex"
 	"encoding/json"
-	"fmt"
+	"errors"
+	"io"
+	"log/slog"
 	"net/http"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/timlinux/baboon/stats"
+	"github.com/timlinux/baboon/websocket"
+	"github.com/timlinux/baboon/words"
 )
 
 // Session represents a single game session with its own engine.
+// The engine is not safe for concurrent use, so every call into it is made
+// while holding the session's own mutex; requests for different sessions
+// never wait on each other.
 type Session struct {
 	ID        string
+	Profile   string
 	Engine    *Engine
 	CreatedAt time.Time
-	LastUsed  time.Time
+	LastUsed  time.Time // Guarded by Server.mu
+
+	mu sync.Mutex
 }
 
 // Server provides a RESTful API for the game engine.
 // It supports multiple concurrent sessions, each with their own game state.
+// Sessions playing as the same profile share one historical stats store.
 type Server struct {
-	config   Config
-	sessions map[string]*Session
-	mu       sync.RWMutex
-	addr     string
+	config     Config
+	sessions   map[string]*Session
+	history    *HistoryStore
+	mu         sync.RWMutex // Guards the sessions map only
+	addr       string
+	httpServer *http.Server
+
+	wsMu    sync.Mutex // Guards wsConns
+	wsConns map[*websocket.Conn]struct{}
+
+	tokens  *Tokens // Required bearer tokens; nil accepts every request
+	metrics *metrics
+	logger  *slog.Logger
+	hooks   *Hooks // Nil if no hooks are configured
 }
 
 // NewServer creates a new REST API server with the given configuration.
 func NewServer(config Config, addr string) (*Server, error) {
-	return &Server{
+	s := &Server{
 		config:   config,
 		sessions: make(map[string]*Session),
+		history:  NewHistoryStore(config.Storage),
 		addr:     addr,
-	}, nil
+		wsConns:  make(map[*websocket.Conn]struct{}),
+		metrics:  newMetrics(),
+		logger:   slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+	s.httpServer = &http.Server{
+		Addr:    addr,
+		Handler: s.routes(),
+	}
+	// WebSocket connections are hijacked, so Shutdown does not close them
+	s.httpServer.RegisterOnShutdown(s.closeWebSockets)
+	if config.Hooks.Enabled() {
+		s.hooks = NewHooks(config.Hooks, s.logger)
+	}
+	return s, nil
 }
 
 // GetAddr returns the server address.
@@ -63,45 +99,134 @@ func (s *Server) getSession(id string) (*Session, bool) {
 	return session, exists
 }
 
-// Start starts the HTTP server. This is a blocking call.
+// Start starts the HTTP server, or the HTTPS server if EnableTLS was
+// called. This is a blocking call. After Shutdown it returns
+// http.ErrServerClosed.
 func (s *Server) Start() error {
-	mux := http.NewServeMux()
-
-	// Session management
-	mux.HandleFunc("POST /api/sessions", s.handleCreateSession)
-	mux.HandleFunc("DELETE /api/sessions/{id}", s.handleDeleteSession)
-	mux.HandleFunc("GET /api/sessions", s.handleListSessions)
+	if s.usesTLS() {
+		// The certificate is already in the TLS configuration
+		return s.httpServer.ListenAndServeTLS("", "")
+	}
+	return s.httpServer.ListenAndServe()
+}
 
-	// Game lifecycle (session-specific)
-	mux.HandleFunc("POST /api/sessions/{id}/round", s.handleStartRound)
+// ShutdownReport describes what was flushed when the server stopped.
+type ShutdownReport struct {
+	Sessions      int            // Sessions active when the server stopped
+	RoundsFlushed map[string]int // Unsaved rounds written to disk, per profile
+}
 
-	// Input handling (session-specific)
-	mux.HandleFunc("POST /api/sessions/{id}/keystroke", s.handleKeystroke)
-	mux.HandleFunc("POST /api/sessions/{id}/backspace", s.handleBackspace)
-	mux.HandleFunc("POST /api/sessions/{id}/space", s.handleSpace)
+// Shutdown stops accepting connections, waits for in-flight requests to
+// finish (or ctx to expire), then saves the stats of every active session
+// and waits for queued round hooks. Stats are saved even if draining times
+// out; the returned error reports drain, save and hook failures.
+func (s *Server) Shutdown(ctx context.Context) (ShutdownReport, error) {
+	drainErr := s.httpServer.Shutdown(ctx)
 
-	// State queries (session-specific)
-	mux.HandleFunc("GET /api/sessions/{id}/state", s.handleGetState)
-	mux.HandleFunc("GET /api/sessions/{id}/stats/session", s.handleGetSessionStats)
-	mux.HandleFunc("GET /api/sessions/{id}/stats/historical", s.handleGetHistoricalStats)
+	s.mu.RLock()
+	report := ShutdownReport{Sessions: len(s.sessions)}
+	s.mu.RUnlock()
 
-	// Persistence (session-specific)
-	mux.HandleFunc("POST /api/sessions/{id}/save", s.handleSaveStats)
+	flushed, saveErr := s.history.SaveAll()
+	report.RoundsFlushed = flushed
+	if saveErr != nil {
+		s.logger.Error("saving stats at shutdown failed", "error", saveErr)
+	}
 
-	// Timing submission (session-specific)
-	mux.HandleFunc("POST /api/sessions/{id}/timing", s.handleSubmitTiming)
+	var hookErr error
+	if s.hooks != nil {
+		hookErr = s.hooks.close(ctx)
+	}
 
-	// Health check
-	mux.HandleFunc("GET /api/health", s.handleHealth)
+	return report, errors.Join(drainErr, saveErr, hookErr)
+}
 
-	return http.ListenAndServe(s.addr, mux)
+// routes builds the HTTP handler for all API endpoints.
+func (s *Server) routes() http.Handler {
+	mux := http.NewServeMux()
+	for _, r := range s.routeTable() {
+		handler := r.handler
+		if !r.public {
+			handler = s.authenticate(handler)
+		}
+		handler = s.instrument(r, handler)
+		mux.HandleFunc(r.method+" "+r.path, handler)
+	}
+	return mux
+}
+
+// routeTable lists every API endpoint. It is the single source for both the
+// HTTP mux and the OpenAPI document, so the two cannot disagree.
+func (s *Server) routeTable() []route {
+	return []route{
+		// Session management
+		{method: "POST", path: "/api/sessions", handler: s.handleCreateSession, status: http.StatusCreated,
+			summary: "Create a session", request: CreateSessionRequest{Profile: "default"}, response: CreateSessionResponse{},
+			optionalBody: true},
+		{method: "DELETE", path: "/api/sessions/{id}", handler: s.handleDeleteSession, status: http.StatusNoContent,
+			summary: "Delete a session"},
+		{method: "GET", path: "/api/sessions", handler: s.handleListSessions,
+			summary: "List active sessions", response: ListSessionsResponse{}},
+
+		// Game lifecycle (session-specific)
+		{method: "POST", path: "/api/sessions/{id}/round", handler: s.handleStartRound,
+			summary: "Start a new round", response: StatusResponse{}},
+		{method: "POST", path: "/api/sessions/{id}/abandon", handler: s.handleAbandonRound,
+			summary: "Abandon the round in progress", request: AbandonRequest{KeepPartial: true}, response: AbandonResponse{},
+			optionalBody: true},
+
+		// Input handling (session-specific)
+		{method: "POST", path: "/api/sessions/{id}/keystroke", handler: s.handleKeystroke,
+			summary: "Process a keystroke", request: KeystrokeRequest{Char: "a", SeekTimeMs: 150}, response: KeystrokeResponse{}},
+		{method: "POST", path: "/api/sessions/{id}/backspace", handler: s.handleBackspace,
+			summary: "Process a backspace", response: BackspaceResponse{}},
+		{method: "POST", path: "/api/sessions/{id}/space", handler: s.handleSpace,
+			summary: "Process a space", request: SpaceRequest{SeekTimeMs: 200}, response: SpaceResponse{},
+			optionalBody: true},
+		{method: "POST", path: "/api/sessions/{id}/input", handler: s.handleInput,
+			summary:  "Process a batch of input events",
+			request:  InputRequest{Events: []InputEvent{{Type: InputKeystroke, Char: "a", SeekTimeMs: 150}, {Type: InputBackspace}}},
+			response: InputResponse{}},
+
+		// State queries (session-specific)
+		{method: "GET", path: "/api/sessions/{id}/state", handler: s.handleGetState,
+			summary: "Get the game state", response: GameStateResponse{}},
+		{method: "GET", path: "/api/sessions/{id}/stats/session", handler: s.handleGetSessionStats,
+			summary: "Get statistics for the current round", response: stats.Stats{}},
+		{method: "GET", path: "/api/sessions/{id}/stats/historical", handler: s.handleGetHistoricalStats,
+			summary: "Get historical statistics for the session's profile", response: stats.HistoricalStats{}},
+		{method: "GET", path: "/api/sessions/{id}/stats/trends", handler: s.handleGetTrends,
+			summary: "Get rolling averages and trends", response: stats.TrendReport{}},
+
+		// Persistence (session-specific)
+		{method: "POST", path: "/api/sessions/{id}/save", handler: s.handleSaveStats,
+			summary: "Save historical statistics", response: StatusResponse{}},
+
+		// Timing submission (session-specific)
+		{method: "POST", path: "/api/sessions/{id}/timing", handler: s.handleSubmitTiming,
+			summary:  "Submit the timing of a completed round",
+			request:  TimingRequest{StartTimeUnixMs: 1705312200000, EndTimeUnixMs: 1705312257000, DurationMs: 57000},
+			response: StatusResponse{}},
+
+		// WebSocket transport (session-specific)
+		{method: "GET", path: "/api/sessions/{id}/ws", handler: s.handleWebSocket, status: http.StatusSwitchingProtocols,
+			summary: "Open a WebSocket carrying the session operations (protocol " + WSSubprotocol + ")"},
+
+		// Health check and API description
+		{method: "GET", path: "/api/health", handler: s.handleHealth,
+			summary: "Check the server is running", response: HealthResponse{}, public: true},
+		{method: "GET", path: "/api/openapi.json", handler: s.handleOpenAPI,
+			summary: "Get this OpenAPI document", response: map[string]any{}},
+		{method: "GET", path: "/metrics", handler: s.handleMetrics,
+			summary: "Get server metrics in the Prometheus text format", plainText: true, public: true},
+	}
 }
 
 // StartAsync starts the HTTP server in a goroutine.
 func (s *Server) StartAsync() {
 	go func() {
 		if err := s.Start(); err != nil && err != http.ErrServerClosed {
-			fmt.Printf("Server error: %v\n", err)
+			s.logger.Error("server stopped", "error", err)
 		}
 	}()
 }
@@ -110,7 +235,10 @@ func (s *Server) StartAsync() {
 
 // CreateSessionRequest is the request body for POST /api/sessions
 type CreateSessionRequest struct {
-	PunctuationMode bool `json:"punctuation_mode"`
+	PunctuationMode bool   `json:"punctuation_mode"`
+	QuoteMode       bool   `json:"quote_mode"`             // Type passages from the quote corpus
+	QuoteLength     string `json:"quote_length,omitempty"` // short, medium or long (default: any)
+	Profile         string `json:"profile"`                // Stats profile (default: "default")
 }
 
 // CreateSessionResponse is the response body for POST /api/sessions
@@ -121,6 +249,7 @@ type CreateSessionResponse struct {
 // SessionInfo provides information about a session
 type SessionInfo struct {
 	ID        string    `json:"id"`
+	Profile   string    `json:"profile"`
 	CreatedAt time.Time `json:"created_at"`
 	LastUsed  time.Time `json:"last_used"`
 }
@@ -136,11 +265,29 @@ type KeystrokeRequest struct {
 	SeekTimeMs int64  `json:"seek_time_ms,omitempty"` // Frontend-measured seek time (optional)
 }
 
+// validate checks the keystroke is a single character with a sane seek time.
+func (req KeystrokeRequest) validate() error {
+	if err := validateChar(req.Char); err != nil {
+		return err
+	}
+	return validateSeekTime(req.SeekTimeMs)
+}
+
 // SpaceRequest is the request body for POST /api/sessions/{id}/space
 type SpaceRequest struct {
 	SeekTimeMs int64 `json:"seek_time_ms,omitempty"` // Frontend-measured seek time (optional)
 }
 
+// AbandonRequest is the request body for POST /api/sessions/{id}/abandon
+type AbandonRequest struct {
+	KeepPartial bool `json:"keep_partial,omitempty"` // Merge the letters typed so far into the historical stats
+}
+
+// AbandonResponse is the response body for POST /api/sessions/{id}/abandon
+type AbandonResponse struct {
+	Abandoned bool `json:"abandoned"` // False if no round was in progress
+}
+
 // TimingRequest is the request body for POST /api/sessions/{id}/timing
 type TimingRequest struct {
 	StartTimeUnixMs int64 `json:"start_time_unix_ms"` // Unix milliseconds
@@ -167,6 +314,50 @@ type SpaceResponse struct {
 	TreatedAsError bool `json:"treated_as_error"`
 }
 
+// Input event types accepted by POST /api/sessions/{id}/input
+const (
+	InputKeystroke = "keystroke"
+	InputBackspace = "backspace"
+	InputSpace     = "space"
+	InputPause     = "pause" // The player paused; see GameAPI.PauseRound
+	InputStart     = "start" // A countdown reached "go"; see GameAPI.StartTimer
+)
+
+// MaxInputEvents is the largest batch accepted by POST /api/sessions/{id}/input
+const MaxInputEvents = 256
+
+// InputEvent is a single keystroke, backspace, space, pause or start in an input batch
+type InputEvent struct {
+	Type       string `json:"type"`                   // keystroke, backspace, space, pause or start
+	Char       string `json:"char,omitempty"`         // Typed character (keystroke only)
+	SeekTimeMs int64  `json:"seek_time_ms,omitempty"` // Frontend-measured seek time (optional)
+}
+
+// InputRequest is the request body for POST /api/sessions/{id}/input.
+// Events are applied in order.
+type InputRequest struct {
+	Events []InputEvent `json:"events"`
+}
+
+// InputEventResult is the outcome of one event in an input batch. Only the
+// fields relevant to the event type are set.
+type InputEventResult struct {
+	Type           string `json:"type"`
+	IsCorrect      bool   `json:"is_correct,omitempty"`
+	TimerStarted   bool   `json:"timer_started,omitempty"`
+	CharIndex      int    `json:"char_index,omitempty"`
+	Removed        bool   `json:"removed,omitempty"`
+	Advanced       bool   `json:"advanced,omitempty"`
+	RoundComplete  bool   `json:"round_complete,omitempty"`
+	TreatedAsError bool   `json:"treated_as_error,omitempty"`
+}
+
+// InputResponse is the response body for POST /api/sessions/{id}/input
+type InputResponse struct {
+	Results []InputEventResult `json:"results"`
+	State   GameStateResponse  `json:"state"`
+}
+
 // GameStateResponse is the response body for GET /api/sessions/{id}/state
 type GameStateResponse struct {
 	Words           []string `json:"words"`
@@ -181,6 +372,12 @@ type GameStateResponse struct {
 	PreviousWord    string   `json:"previous_word"`
 	NextWord        string   `json:"next_word"`
 	NextWords       []string `json:"next_words"`
+	QuoteSource     string   `json:"quote_source,omitempty"`
+}
+
+// StatusResponse is the response body for operations with no result
+type StatusResponse struct {
+	Status string `json:"status"`
 }
 
 // HealthResponse is the response body for GET /api/health
@@ -193,26 +390,51 @@ type HealthResponse struct {
 
 func (s *Server) handleCreateSession(w http.ResponseWriter, r *http.Request) {
 	var req CreateSessionRequest
-	// Decode request body (optional - use defaults if not provided)
-	json.NewDecoder(r.Body).Decode(&req)
+	// The body is optional: defaults are used if it is empty
+	if err := decodeBody(w, r, &req, true); err != nil {
+		writeError(w, err)
+		return
+	}
 
 	// Create config for this session
 	config := s.config
 	if req.PunctuationMode {
 		config.PunctuationMode = true
 	}
+	if req.QuoteMode {
+		if !words.ValidQuoteLength(req.QuoteLength) {
+			writeError(w, invalidRequest("unknown quote length %q (use short, medium or long)", req.QuoteLength))
+			return
+		}
+		config.QuoteMode = true
+		config.QuoteLength = req.QuoteLength
+	}
+
+	if req.Profile == "" {
+		req.Profile = stats.DefaultProfile
+	}
+	if !stats.ValidProfileName(req.Profile) {
+		writeError(w, invalidRequest("invalid profile name %q", req.Profile))
+		return
+	}
+	if profile, ok := authenticatedProfile(r); ok && profile != req.Profile {
+		writeError(w, errForbidden("token does not grant access to profile "+req.Profile))
+		return
+	}
 
-	// Create new engine for this session
-	engine, err := NewEngine(config)
+	// Create new engine for this session, sharing the profile's history
+	history, err := s.history.Get(req.Profile)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, err)
 		return
 	}
+	engine := NewEngineWithHistory(config, history)
 
 	// Generate session ID and store
 	sessionID := generateSessionID()
 	session := &Session{
 		ID:        sessionID,
+		Profile:   req.Profile,
 		Engine:    engine,
 		CreatedAt: time.Now(),
 		LastUsed:  time.Now(),
@@ -221,6 +443,7 @@ func (s *Server) handleCreateSession(w http.ResponseWriter, r *http.Request) {
 	s.mu.Lock()
 	s.sessions[sessionID] = session
 	s.mu.Unlock()
+	s.sessionLogger(session).Info("session created", "punctuation", config.PunctuationMode, "quotes", config.QuoteMode)
 
 	resp := CreateSessionResponse{SessionID: sessionID}
 	w.Header().Set("Content-Type", "application/json")
@@ -232,26 +455,34 @@ func (s *Server) handleDeleteSession(w http.ResponseWriter, r *http.Request) {
 	sessionID := r.PathValue("id")
 
 	s.mu.Lock()
-	_, exists := s.sessions[sessionID]
+	session, exists := s.sessions[sessionID]
 	if exists {
 		delete(s.sessions, sessionID)
 	}
 	s.mu.Unlock()
 
 	if !exists {
-		http.Error(w, "session not found", http.StatusNotFound)
+		writeError(w, errSessionNotFound)
 		return
 	}
+	s.sessionLogger(session).Info("session deleted")
 
 	w.WriteHeader(http.StatusNoContent)
 }
 
 func (s *Server) handleListSessions(w http.ResponseWriter, r *http.Request) {
+	profile, authenticated := authenticatedProfile(r)
+
 	s.mu.RLock()
 	sessions := make([]SessionInfo, 0, len(s.sessions))
 	for _, session := range s.sessions {
+		// Only list the sessions the token grants access to
+		if authenticated && session.Profile != profile {
+			continue
+		}
 		sessions = append(sessions, SessionInfo{
 			ID:        session.ID,
+			Profile:   session.Profile,
 			CreatedAt: session.CreatedAt,
 			LastUsed:  session.LastUsed,
 		})
@@ -264,108 +495,121 @@ func (s *Server) handleListSessions(w http.ResponseWriter, r *http.Request) {
 }
 
 func (s *Server) handleStartRound(w http.ResponseWriter, r *http.Request) {
-	sessionID := r.PathValue("id")
-	session, exists := s.getSession(sessionID)
-	if !exists {
-		http.Error(w, "session not found", http.StatusNotFound)
-		return
-	}
-
-	s.mu.Lock()
-	session.Engine.StartRound()
-	s.mu.Unlock()
-
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+	result, err := s.startRound(r.PathValue("id"))
+	writeResult(w, result, err)
 }
 
-func (s *Server) handleKeystroke(w http.ResponseWriter, r *http.Request) {
-	sessionID := r.PathValue("id")
-	session, exists := s.getSession(sessionID)
-	if !exists {
-		http.Error(w, "session not found", http.StatusNotFound)
+func (s *Server) handleAbandonRound(w http.ResponseWriter, r *http.Request) {
+	var req AbandonRequest
+	if err := decodeBody(w, r, &req, true); err != nil {
+		writeError(w, err)
 		return
 	}
+	result, err := s.abandonRound(r.PathValue("id"), req)
+	writeResult(w, result, err)
+}
 
+func (s *Server) handleKeystroke(w http.ResponseWriter, r *http.Request) {
 	var req KeystrokeRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	if err := decodeBody(w, r, &req, false); err != nil {
+		writeError(w, err)
 		return
 	}
-
-	s.mu.Lock()
-	// Use timing-aware method with frontend-provided seek time
-	result := session.Engine.ProcessKeystrokeWithTiming(req.Char, req.SeekTimeMs)
-	s.mu.Unlock()
-
-	resp := KeystrokeResponse{
-		IsCorrect:    result.IsCorrect,
-		TimerStarted: result.TimerStarted,
-		CharIndex:    result.CharIndex,
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(resp)
+	result, err := s.processKeystroke(r.PathValue("id"), req)
+	writeResult(w, result, err)
 }
 
 func (s *Server) handleBackspace(w http.ResponseWriter, r *http.Request) {
-	sessionID := r.PathValue("id")
-	session, exists := s.getSession(sessionID)
-	if !exists {
-		http.Error(w, "session not found", http.StatusNotFound)
-		return
-	}
-
-	s.mu.Lock()
-	removed := session.Engine.ProcessBackspace()
-	s.mu.Unlock()
-
-	resp := BackspaceResponse{Removed: removed}
-
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(resp)
+	result, err := s.processBackspace(r.PathValue("id"))
+	writeResult(w, result, err)
 }
 
 func (s *Server) handleSpace(w http.ResponseWriter, r *http.Request) {
-	sessionID := r.PathValue("id")
-	session, exists := s.getSession(sessionID)
-	if !exists {
-		http.Error(w, "session not found", http.StatusNotFound)
-		return
-	}
-
-	// Parse optional seek time from request body
+	// The body, carrying the seek time, is optional
 	var req SpaceRequest
-	json.NewDecoder(r.Body).Decode(&req) // Ignore errors, use zero values
-
-	s.mu.Lock()
-	// Use timing-aware method with frontend-provided seek time
-	result := session.Engine.ProcessSpaceWithTiming(req.SeekTimeMs)
-	s.mu.Unlock()
-
-	resp := SpaceResponse{
-		Advanced:       result.Advanced,
-		RoundComplete:  result.RoundComplete,
-		TreatedAsError: result.TreatedAsError,
+	if err := decodeBody(w, r, &req, true); err != nil {
+		writeError(w, err)
+		return
 	}
-
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(resp)
+	result, err := s.processSpace(r.PathValue("id"), req)
+	writeResult(w, result, err)
 }
 
 func (s *Server) handleGetState(w http.ResponseWriter, r *http.Request) {
-	sessionID := r.PathValue("id")
-	session, exists := s.getSession(sessionID)
-	if !exists {
-		http.Error(w, "session not found", http.StatusNotFound)
+	result, err := s.gameState(r.PathValue("id"))
+	writeResult(w, result, err)
+}
+
+func (s *Server) handleInput(w http.ResponseWriter, r *http.Request) {
+	var req InputRequest
+	if err := decodeBody(w, r, &req, false); err != nil {
+		writeError(w, err)
 		return
 	}
+	result, err := s.processInput(r.PathValue("id"), req)
+	writeResult(w, result, err)
+}
 
-	s.mu.RLock()
-	state := session.Engine.GetGameState()
-	s.mu.RUnlock()
+// validateInput checks a whole input batch before any of it is applied, so a
+// bad event never leaves the batch half applied.
+func validateInput(events []InputEvent) error {
+	if len(events) > MaxInputEvents {
+		return invalidRequest("too many events (max %d)", MaxInputEvents)
+	}
+	for i, event := range events {
+		var err error
+		switch event.Type {
+		case InputKeystroke:
+			err = KeystrokeRequest{Char: event.Char, SeekTimeMs: event.SeekTimeMs}.validate()
+		case InputBackspace, InputSpace:
+			err = validateSeekTime(event.SeekTimeMs)
+		case InputPause, InputStart:
+		default:
+			return invalidRequest("event %d: unknown type %q", i, event.Type)
+		}
+		if err != nil {
+			return invalidRequest("event %d: %v", i, err)
+		}
+	}
+	return nil
+}
+
+// applyInput applies a validated input batch to a session's engine.
+func applyInput(session *Session, events []InputEvent) InputResponse {
+	resp := InputResponse{Results: make([]InputEventResult, len(events))}
+
+	session.mu.Lock()
+	defer session.mu.Unlock()
+
+	for i, event := range events {
+		result := InputEventResult{Type: event.Type}
+		switch event.Type {
+		case InputKeystroke:
+			k := session.Engine.ProcessKeystrokeWithTiming(event.Char, event.SeekTimeMs)
+			result.IsCorrect = k.IsCorrect
+			result.TimerStarted = k.TimerStarted
+			result.CharIndex = k.CharIndex
+		case InputBackspace:
+			result.Removed = session.Engine.ProcessBackspace()
+		case InputSpace:
+			sp := session.Engine.ProcessSpaceWithTiming(event.SeekTimeMs)
+			result.Advanced = sp.Advanced
+			result.RoundComplete = sp.RoundComplete
+			result.TreatedAsError = sp.TreatedAsError
+		case InputPause:
+			session.Engine.PauseRound()
+		case InputStart:
+			session.Engine.StartTimer()
+		}
+		resp.Results[i] = result
+	}
+	resp.State = newGameStateResponse(session.Engine.GetGameState())
+	return resp
+}
 
-	resp := GameStateResponse{
+// newGameStateResponse converts a game state to its JSON representation.
+func newGameStateResponse(state GameState) GameStateResponse {
+	return GameStateResponse{
 		Words:           state.Words,
 		CurrentWordIdx:  state.CurrentWordIdx,
 		CurrentInput:    state.CurrentInput,
@@ -378,89 +622,38 @@ func (s *Server) handleGetState(w http.ResponseWriter, r *http.Request) {
 		PreviousWord:    state.PreviousWord,
 		NextWord:        state.NextWord,
 		NextWords:       state.NextWords,
+		QuoteSource:     state.QuoteSource,
 	}
-
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(resp)
 }
 
 func (s *Server) handleGetSessionStats(w http.ResponseWriter, r *http.Request) {
-	sessionID := r.PathValue("id")
-	session, exists := s.getSession(sessionID)
-	if !exists {
-		http.Error(w, "session not found", http.StatusNotFound)
-		return
-	}
-
-	s.mu.RLock()
-	sessionStats := session.Engine.GetSessionStats()
-	s.mu.RUnlock()
-
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(sessionStats)
+	result, err := s.sessionStats(r.PathValue("id"))
+	writeResult(w, result, err)
 }
 
 func (s *Server) handleGetHistoricalStats(w http.ResponseWriter, r *http.Request) {
-	sessionID := r.PathValue("id")
-	session, exists := s.getSession(sessionID)
-	if !exists {
-		http.Error(w, "session not found", http.StatusNotFound)
-		return
-	}
-
-	s.mu.RLock()
-	historicalStats := session.Engine.GetHistoricalStats()
-	s.mu.RUnlock()
+	result, err := s.historicalStats(r.PathValue("id"))
+	writeResult(w, result, err)
+}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(historicalStats)
+func (s *Server) handleGetTrends(w http.ResponseWriter, r *http.Request) {
+	result, err := s.trends(r.PathValue("id"))
+	writeResult(w, result, err)
 }
 
 func (s *Server) handleSaveStats(w http.ResponseWriter, r *http.Request) {
-	sessionID := r.PathValue("id")
-	session, exists := s.getSession(sessionID)
-	if !exists {
-		http.Error(w, "session not found", http.StatusNotFound)
-		return
-	}
-
-	s.mu.Lock()
-	err := session.Engine.SaveStats()
-	s.mu.Unlock()
-
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
-
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+	result, err := s.saveStats(r.PathValue("id"))
+	writeResult(w, result, err)
 }
 
 func (s *Server) handleSubmitTiming(w http.ResponseWriter, r *http.Request) {
-	sessionID := r.PathValue("id")
-	session, exists := s.getSession(sessionID)
-	if !exists {
-		http.Error(w, "session not found", http.StatusNotFound)
-		return
-	}
-
 	var req TimingRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	if err := decodeBody(w, r, &req, false); err != nil {
+		writeError(w, err)
 		return
 	}
-
-	// Convert Unix milliseconds to time.Time
-	startTime := time.UnixMilli(req.StartTimeUnixMs)
-	endTime := time.UnixMilli(req.EndTimeUnixMs)
-
-	s.mu.Lock()
-	session.Engine.SubmitTiming(startTime, endTime, req.DurationMs)
-	s.mu.Unlock()
-
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+	result, err := s.submitTiming(r.PathValue("id"), req)
+	writeResult(w, result, err)
 }
 
 func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
@@ -0,0 +1,431 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/timlinux/baboon/stats"
+)
+
+// Seek time distributions for TypistModel.Distribution
+const (
+	SeekLogNormal = "lognormal" // Right-skewed, like real typing: mostly steady with occasional pauses
+	SeekNormal    = "normal"
+	SeekConstant  = "constant"
+)
+
+// TypistModel describes a synthetic typist for Simulate.
+type TypistModel struct {
+	// TargetWPM sets the mean seek time to 12000/TargetWPM ms: one key
+	// press per fifth of a word, where the spaces that advance to the next
+	// word and backspaces are key presses too. The engine's raw WPM counts
+	// only the characters typed into words, so it comes out lower by their
+	// share of the presses (see SimulationReport.ExpectedRawWPM), and its
+	// WPM counts only correct characters, so that is lower again.
+	TargetWPM float64
+
+	// Distribution of seek times around the mean: SeekLogNormal,
+	// SeekNormal or SeekConstant.
+	Distribution string
+
+	// Jitter is the seek times' standard deviation as a fraction of the mean.
+	Jitter float64
+
+	// ErrorRate is the chance that a letter is mistyped as a neighbouring
+	// key. FingerErrorRates overrides it for particular fingers (see
+	// stats.KeyToFinger).
+	ErrorRate        float64
+	FingerErrorRates map[int]float64
+
+	// CorrectionRate is the chance that a mistyped letter is noticed and
+	// fixed with backspace; the rest are left in the word.
+	CorrectionRate float64
+
+	// NoticeDelay is the most further letters typed before an error is
+	// noticed, all of which are then backspaced and retyped.
+	NoticeDelay int
+
+	// SameFingerSlowdown multiplies the seek time of same-finger bigrams.
+	SameFingerSlowdown float64
+
+	// Realtime waits out each seek time, so a server sees keystrokes at
+	// the typist's pace. Otherwise keys are sent as fast as possible and
+	// the round is timed by the sum of the seek times.
+	Realtime bool
+}
+
+// DefaultTypistModel returns a steady 60 WPM typist who makes a few
+// mistakes and fixes most of them.
+func DefaultTypistModel() TypistModel {
+	return TypistModel{
+		TargetWPM:          60,
+		Distribution:       SeekLogNormal,
+		Jitter:             0.35,
+		ErrorRate:          0.03,
+		CorrectionRate:     0.8,
+		NoticeDelay:        1,
+		SameFingerSlowdown: 1.3,
+	}
+}
+
+// Validate checks the model's parameters are in range.
+func (m TypistModel) Validate() error {
+	switch {
+	case m.TargetWPM <= 0:
+		return errors.New("target WPM must be positive")
+	case m.Distribution != SeekLogNormal && m.Distribution != SeekNormal && m.Distribution != SeekConstant:
+		return fmt.Errorf("unknown seek time distribution %q (use %s, %s or %s)",
+			m.Distribution, SeekLogNormal, SeekNormal, SeekConstant)
+	case m.Jitter < 0:
+		return errors.New("jitter must not be negative")
+	case m.ErrorRate < 0 || m.ErrorRate > 1:
+		return errors.New("error rate must be between 0 and 1")
+	case m.CorrectionRate < 0 || m.CorrectionRate > 1:
+		return errors.New("correction rate must be between 0 and 1")
+	case m.NoticeDelay < 0:
+		return errors.New("notice delay must not be negative")
+	case m.SameFingerSlowdown < 0:
+		return errors.New("same-finger slowdown must not be negative")
+	}
+	for finger, rate := range m.FingerErrorRates {
+		if _, ok := stats.FingerNames[finger]; !ok {
+			return fmt.Errorf("unknown finger %d", finger)
+		}
+		if rate < 0 || rate > 1 {
+			return fmt.Errorf("error rate for %s must be between 0 and 1", stats.FingerNames[finger])
+		}
+	}
+	return nil
+}
+
+// errorRate returns the chance of mistyping c.
+func (m TypistModel) errorRate(c byte) float64 {
+	if rate, ok := m.FingerErrorRates[stats.GetFinger(rune(c))]; ok {
+		return rate
+	}
+	return m.ErrorRate
+}
+
+// keyboardRows are the letter rows, for choosing a neighbouring wrong key
+var keyboardRows = []string{"qwertyuiop", "asdfghjkl", "zxcvbnm"}
+
+// typist plays rounds according to a model.
+type typist struct {
+	model TypistModel
+	rng   *rand.Rand
+}
+
+// seekTime returns the time in ms taken to press next after prev.
+func (t *typist) seekTime(prev, next byte) int64 {
+	mean := 12000 / t.model.TargetWPM
+	if stats.IsSameFingerBigram(rune(prev), rune(next)) && t.model.SameFingerSlowdown > 0 {
+		mean *= t.model.SameFingerSlowdown
+	}
+
+	ms := mean
+	switch t.model.Distribution {
+	case SeekLogNormal:
+		sigma := math.Sqrt(math.Log(1 + t.model.Jitter*t.model.Jitter))
+		ms = math.Exp(math.Log(mean) - sigma*sigma/2 + sigma*t.rng.NormFloat64())
+	case SeekNormal:
+		ms = mean + mean*t.model.Jitter*t.rng.NormFloat64()
+	}
+	// Keep within what the server accepts, and never instantaneous
+	return int64(math.Min(math.Max(ms, 1), MaxSeekTimeMs))
+}
+
+// wrongKey returns a key beside c on the same row.
+func (t *typist) wrongKey(c byte) byte {
+	for _, row := range keyboardRows {
+		for i := 0; i < len(row); i++ {
+			if row[i] != c {
+				continue
+			}
+			if i == 0 || (i < len(row)-1 && t.rng.Intn(2) == 0) {
+				return row[i+1]
+			}
+			return row[i-1]
+		}
+	}
+	// Punctuation is mistyped as a letter
+	return 'a' + byte(t.rng.Intn(26))
+}
+
+// roundResult is one simulated round.
+type roundResult struct {
+	stats      *stats.Stats
+	keystrokes int
+	characters int // Keystrokes the engine counts towards raw WPM
+	backspaces int
+	letters    map[int]int // Letters presented per finger
+	elapsed    time.Duration
+}
+
+// playRound types one round on api and submits its timing.
+func (t *typist) playRound(ctx context.Context, api GameAPI) (roundResult, error) {
+	result := roundResult{letters: make(map[int]int)}
+	api.StartRound()
+	words := api.GetGameState().Words
+	if len(words) == 0 {
+		return result, errors.New("round has no words")
+	}
+
+	var elapsed int64 // Since the timer started, in ms
+	started := false
+	press := func(prev, c byte) int64 {
+		seek := t.seekTime(prev, c)
+		if !started {
+			// The first key starts the timer, so it has no seek time
+			started, seek = true, 0
+		}
+		elapsed += seek
+		if t.model.Realtime {
+			time.Sleep(time.Duration(seek) * time.Millisecond)
+		}
+		return seek
+	}
+	typeKey := func(prev, c byte) {
+		api.ProcessKeystrokeWithTiming(string(c), press(prev, c))
+		result.keystrokes++
+		result.characters++
+	}
+
+	for wordIdx, word := range words {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		prev := byte(' ')
+		for i := 0; i < len(word); i++ {
+			c := word[i]
+			if finger := stats.GetFinger(rune(c)); finger >= 0 {
+				result.letters[finger]++
+			}
+			// The first key is always right, as only it starts the timer
+			firstKey := wordIdx == 0 && i == 0
+			if firstKey || t.rng.Float64() >= t.model.errorRate(c) {
+				typeKey(prev, c)
+				prev = c
+				continue
+			}
+
+			wrong := t.wrongKey(c)
+			typeKey(prev, wrong)
+			if t.rng.Float64() >= t.model.CorrectionRate {
+				prev = wrong
+				continue
+			}
+
+			// Type on a little before noticing, then back up and retype
+			ahead := min(t.rng.Intn(t.model.NoticeDelay+1), len(word)-i-1)
+			last := wrong
+			for j := 1; j <= ahead; j++ {
+				typeKey(last, word[i+j])
+				last = word[i+j]
+			}
+			for j := 0; j <= ahead; j++ {
+				elapsed += t.seekTime(last, last)
+				api.ProcessBackspace()
+				result.backspaces++
+			}
+			typeKey(prev, c)
+			prev = c
+		}
+
+		space := api.ProcessSpaceWithTiming(press(prev, ' '))
+		result.keystrokes++
+		if space.TreatedAsError {
+			result.characters++
+		}
+		if space.RoundComplete {
+			break
+		}
+	}
+
+	result.elapsed = time.Duration(elapsed) * time.Millisecond
+	end := time.Now()
+	api.SubmitTiming(end.Add(-result.elapsed), end, elapsed)
+	result.stats = api.GetSessionStats()
+	return result, nil
+}
+
+// SimulationEngines returns n in-process engines sharing one profile's
+// history, stored in dir so that simulated rounds never reach real stats.
+func SimulationEngines(n int, config Config, dir string) ([]GameAPI, error) {
+	store := stats.NewJSONStore(filepath.Join(dir, "stats-simulated.json"))
+	history, err := newProfileHistory("simulated", store)
+	if err != nil {
+		return nil, err
+	}
+	apis := make([]GameAPI, n)
+	for i := range apis {
+		apis[i] = NewEngineWithHistory(config, history)
+	}
+	return apis, nil
+}
+
+// SimulationConfig configures a simulation run.
+type SimulationConfig struct {
+	Model  TypistModel
+	Rounds int   // Rounds played by each typist
+	Seed   int64 // Random seed; typists use Seed, Seed+1, ...
+}
+
+// FingerReport compares a finger's configured error rate with what the
+// engine measured.
+type FingerReport struct {
+	Finger       int
+	ErrorRate    float64 // Configured chance of a mistyped letter
+	ExpectedMiss float64 // Expected fraction of letters left wrong: errors not corrected
+	MeasuredMiss float64 // Fraction of letters the engine recorded as never typed correctly
+	FirstShare   float64 // Share of the first round's letters on this finger
+	LastShare    float64 // Share of the last round's letters on this finger
+}
+
+// SimulationReport summarises a simulation run.
+type SimulationReport struct {
+	Typists    int
+	Rounds     int
+	Keystrokes int
+	Backspaces int
+	Wall       time.Duration // Real time taken
+	Typed      time.Duration // Typing time of all rounds, from the seek times
+
+	TargetWPM      float64
+	MeanWPM        float64
+	MinWPM         float64
+	MaxWPM         float64
+	MeanRawWPM     float64
+	ExpectedRawWPM float64 // Raw WPM from the characters typed and the time taken, as the engine should measure it
+	MeanAccuracy   float64
+
+	Fingers []FingerReport
+}
+
+// KeystrokesPerSecond returns the rate keystrokes were sent at in real time.
+func (r SimulationReport) KeystrokesPerSecond() float64 {
+	if r.Wall <= 0 {
+		return 0
+	}
+	return float64(r.Keystrokes+r.Backspaces) / r.Wall.Seconds()
+}
+
+// Simulate plays cfg.Rounds rounds on each api concurrently, one synthetic
+// typist per api, and reports the stats the engine calculated. The apis
+// should share a profile, as the per-finger report compares the profile's
+// historical stats before and after the run.
+func Simulate(ctx context.Context, apis []GameAPI, cfg SimulationConfig) (SimulationReport, error) {
+	report := SimulationReport{Typists: len(apis), TargetWPM: cfg.Model.TargetWPM}
+	if err := cfg.Model.Validate(); err != nil {
+		return report, err
+	}
+	if len(apis) == 0 || cfg.Rounds <= 0 {
+		return report, errors.New("nothing to simulate")
+	}
+
+	before := apis[0].GetHistoricalStats().FingerStats
+	results := make([][]roundResult, len(apis))
+	errs := make([]error, len(apis))
+	start := time.Now()
+
+	var wg sync.WaitGroup
+	for i, api := range apis {
+		wg.Add(1)
+		go func(i int, api GameAPI) {
+			defer wg.Done()
+			t := &typist{model: cfg.Model, rng: rand.New(rand.NewSource(cfg.Seed + int64(i)))}
+			for round := 0; round < cfg.Rounds; round++ {
+				result, err := t.playRound(ctx, api)
+				if err != nil {
+					errs[i] = err
+					return
+				}
+				results[i] = append(results[i], result)
+			}
+		}(i, api)
+	}
+	wg.Wait()
+	report.Wall = time.Since(start)
+
+	var wpmTotal, rawTotal, expectedRawTotal, accuracyTotal float64
+	report.MinWPM = math.Inf(1)
+	for _, typistResults := range results {
+		for _, r := range typistResults {
+			report.Rounds++
+			report.Keystrokes += r.keystrokes
+			report.Backspaces += r.backspaces
+			report.Typed += r.elapsed
+			wpmTotal += r.stats.WPM
+			rawTotal += r.stats.RawWPM
+			if minutes := r.elapsed.Minutes(); minutes > 0 {
+				expectedRawTotal += float64(r.characters) / 5 / minutes
+			}
+			accuracyTotal += r.stats.Accuracy
+			report.MinWPM = math.Min(report.MinWPM, r.stats.WPM)
+			report.MaxWPM = math.Max(report.MaxWPM, r.stats.WPM)
+		}
+	}
+	if report.Rounds == 0 {
+		return report, errors.Join(errs...)
+	}
+	n := float64(report.Rounds)
+	report.MeanWPM = wpmTotal / n
+	report.MeanRawWPM = rawTotal / n
+	report.ExpectedRawWPM = expectedRawTotal / n
+	report.MeanAccuracy = accuracyTotal / n
+
+	report.Fingers = fingerReports(cfg.Model, before, apis[0].GetHistoricalStats().FingerStats, results[0])
+	return report, errors.Join(errs...)
+}
+
+// fingerReports compares each finger's configured and measured errors, and
+// how the share of letters on it changed between the first and last rounds
+// as adaptive selection reacted.
+func fingerReports(model TypistModel, before, after map[int]stats.FingerStat, rounds []roundResult) []FingerReport {
+	share := func(r roundResult, finger int) float64 {
+		total := 0
+		for _, n := range r.letters {
+			total += n
+		}
+		if total == 0 {
+			return 0
+		}
+		return float64(r.letters[finger]) / float64(total)
+	}
+
+	fingers := make([]int, 0, len(stats.FingerNames))
+	for finger := range stats.FingerNames {
+		fingers = append(fingers, finger)
+	}
+	sort.Ints(fingers)
+
+	reports := make([]FingerReport, 0, len(fingers))
+	for _, finger := range fingers {
+		rate := model.ErrorRate
+		if r, ok := model.FingerErrorRates[finger]; ok {
+			rate = r
+		}
+		report := FingerReport{
+			Finger:       finger,
+			ErrorRate:    rate,
+			ExpectedMiss: rate * (1 - model.CorrectionRate),
+		}
+		presented := after[finger].Presented - before[finger].Presented
+		correct := after[finger].Correct - before[finger].Correct
+		if presented > 0 {
+			report.MeasuredMiss = 1 - float64(correct)/float64(presented)
+		}
+		if len(rounds) > 0 {
+			report.FirstShare = share(rounds[0], finger)
+			report.LastShare = share(rounds[len(rounds)-1], finger)
+		}
+		reports = append(reports, report)
+	}
+	return reports
+}
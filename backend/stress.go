@@ -0,0 +1,255 @@
+package backend
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/timlinux/baboon/stats"
+)
+
+// StressConfig configures a concurrency stress run against an in-process server.
+type StressConfig struct {
+	Typists   int           // Sessions typing as fast as they can
+	Savers    int           // Sessions repeatedly saving stats, each as its own profile
+	Duration  time.Duration // How long to run
+	SaveDelay time.Duration // Artificial latency added to every stats save
+	Dir       string        // Directory for the stats files written during the run
+}
+
+// StressReport summarises a stress run. Keystroke latencies are measured
+// end to end over HTTP for the typist sessions.
+type StressReport struct {
+	Keystrokes int
+	Rounds     int // Rounds the typists completed
+	Recorded   int // Rounds found in the typists' historical stats afterwards
+	Saves      int
+	P50        time.Duration
+	P99        time.Duration
+	Max        time.Duration
+	SaveDelay  time.Duration
+}
+
+// Blocked reports whether any keystroke took at least as long as a save,
+// which would mean typists were waiting on another session's disk write.
+func (r StressReport) Blocked() bool {
+	return r.SaveDelay > 0 && r.Max >= r.SaveDelay
+}
+
+// LostRounds returns the number of completed rounds missing from the
+// typists' historical stats.
+func (r StressReport) LostRounds() int {
+	return r.Rounds - r.Recorded
+}
+
+// delayedStore adds a fixed delay to every save, standing in for a slow disk.
+type delayedStore struct {
+	stats.StatsStore
+	delay time.Duration
+}
+
+func (d delayedStore) Save(h *stats.HistoricalStats) error {
+	time.Sleep(d.delay)
+	return d.StatsStore.Save(h)
+}
+
+// RunStress starts a server on a local test listener and runs typist and
+// saver sessions against it concurrently until the duration elapses.
+func RunStress(ctx context.Context, cfg StressConfig) (StressReport, error) {
+	server, err := NewServer(DefaultConfig(), "")
+	if err != nil {
+		return StressReport{}, err
+	}
+	server.history = newHistoryStoreWith(func(profile string) (stats.StatsStore, error) {
+		path := filepath.Join(cfg.Dir, "stats-"+profile+".json")
+		return delayedStore{StatsStore: stats.NewJSONStore(path), delay: cfg.SaveDelay}, nil
+	})
+
+	listener := httptest.NewServer(server.routes())
+	defer listener.Close()
+
+	transport := &http.Transport{MaxIdleConnsPerHost: cfg.Typists + cfg.Savers}
+	defer transport.CloseIdleConnections()
+	client := &stressClient{
+		baseURL: listener.URL,
+		http:    &http.Client{Transport: transport, Timeout: 30 * time.Second},
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, cfg.Duration)
+	defer cancel()
+
+	var (
+		wg        sync.WaitGroup
+		mu        sync.Mutex
+		latencies []time.Duration
+		report    = StressReport{SaveDelay: cfg.SaveDelay}
+		firstErr  error
+	)
+	fail := func(err error) {
+		mu.Lock()
+		if firstErr == nil {
+			firstErr = err
+		}
+		mu.Unlock()
+		cancel()
+	}
+
+	for i := 0; i < cfg.Savers; i++ {
+		id, err := client.createSession(fmt.Sprintf("saver%d", i))
+		if err != nil {
+			return report, err
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for ctx.Err() == nil {
+				if err := client.post(id, "save", nil); err != nil {
+					if ctx.Err() == nil {
+						fail(err)
+					}
+					return
+				}
+				mu.Lock()
+				report.Saves++
+				mu.Unlock()
+			}
+		}()
+	}
+
+	typistProfile := func(i int) string { return fmt.Sprintf("typist%d", i) }
+	for i := 0; i < cfg.Typists; i++ {
+		id, err := client.createSession(typistProfile(i))
+		if err != nil {
+			return report, err
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var local []time.Duration
+			rounds, err := client.typeRounds(ctx, id, &local)
+			mu.Lock()
+			latencies = append(latencies, local...)
+			report.Rounds += rounds
+			mu.Unlock()
+			if err != nil && ctx.Err() == nil {
+				fail(err)
+			}
+		}()
+	}
+
+	wg.Wait()
+	if firstErr != nil {
+		return report, firstErr
+	}
+
+	for i := 0; i < cfg.Typists; i++ {
+		history, err := server.history.Get(typistProfile(i))
+		if err != nil {
+			return report, err
+		}
+		report.Recorded += history.Snapshot().TotalSessions
+	}
+
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	report.Keystrokes = len(latencies)
+	if len(latencies) > 0 {
+		report.P50 = latencies[len(latencies)*50/100]
+		report.P99 = latencies[len(latencies)*99/100]
+		report.Max = latencies[len(latencies)-1]
+	}
+	return report, nil
+}
+
+// stressClient is a minimal HTTP client for the stress harness.
+type stressClient struct {
+	baseURL string
+	http    *http.Client
+}
+
+func (c *stressClient) createSession(profile string) (string, error) {
+	body, _ := json.Marshal(CreateSessionRequest{Profile: profile})
+	resp, err := c.http.Post(c.baseURL+"/api/sessions", "application/json", bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	var result CreateSessionResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", err
+	}
+	return result.SessionID, nil
+}
+
+// post sends a request to a session endpoint.
+func (c *stressClient) post(id, endpoint string, in any) error {
+	var body bytes.Buffer
+	if in != nil {
+		json.NewEncoder(&body).Encode(in)
+	}
+	resp, err := c.http.Post(c.baseURL+"/api/sessions/"+id+"/"+endpoint, "application/json", &body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: status %d", endpoint, resp.StatusCode)
+	}
+	return nil
+}
+
+// typeRounds types complete rounds until ctx is done, recording the latency
+// of every keystroke, and returns the number of rounds completed.
+func (c *stressClient) typeRounds(ctx context.Context, id string, latencies *[]time.Duration) (int, error) {
+	rounds := 0
+	for ctx.Err() == nil {
+		resp, err := c.http.Get(c.baseURL + "/api/sessions/" + id + "/state")
+		if err != nil {
+			return rounds, err
+		}
+		var state GameStateResponse
+		err = json.NewDecoder(resp.Body).Decode(&state)
+		resp.Body.Close()
+		if err != nil {
+			return rounds, err
+		}
+
+		start := time.Now()
+		for _, word := range state.Words {
+			for _, char := range word {
+				if ctx.Err() != nil {
+					return rounds, nil
+				}
+				sent := time.Now()
+				if err := c.post(id, "keystroke", KeystrokeRequest{Char: string(char), SeekTimeMs: 100}); err != nil {
+					return rounds, err
+				}
+				*latencies = append(*latencies, time.Since(sent))
+			}
+			if err := c.post(id, "space", SpaceRequest{SeekTimeMs: 100}); err != nil {
+				return rounds, err
+			}
+		}
+
+		end := time.Now()
+		timing := TimingRequest{
+			StartTimeUnixMs: start.UnixMilli(),
+			EndTimeUnixMs:   end.UnixMilli(),
+			DurationMs:      end.Sub(start).Milliseconds(),
+		}
+		if err := c.post(id, "timing", timing); err != nil {
+			return rounds, err
+		}
+		if err := c.post(id, "round", nil); err != nil {
+			return rounds, err
+		}
+		rounds++
+	}
+	return rounds, nil
+}
@@ -0,0 +1,31 @@
+package backend
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// TestStress runs a short stress run and checks that typists are never held
+// up by other sessions' saves and that every completed round is recorded.
+func TestStress(t *testing.T) {
+	report, err := RunStress(context.Background(), StressConfig{
+		Typists:   4,
+		Savers:    2,
+		Duration:  time.Second,
+		SaveDelay: 250 * time.Millisecond,
+		Dir:       t.TempDir(),
+	})
+	if err != nil {
+		t.Fatalf("RunStress: %v", err)
+	}
+	if report.Rounds == 0 {
+		t.Fatalf("no rounds completed (%d keystrokes)", report.Keystrokes)
+	}
+	if report.Blocked() {
+		t.Errorf("keystroke latency max %s reached the save delay %s", report.Max, report.SaveDelay)
+	}
+	if lost := report.LostRounds(); lost != 0 {
+		t.Errorf("%d of %d completed rounds missing from historical stats", lost, report.Rounds)
+	}
+}
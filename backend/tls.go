@@ -0,0 +1,179 @@
+package backend
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// selfSignedValidity is how long a generated certificate is valid for.
+// Clients pin its fingerprint rather than trusting a CA, so it is long-lived
+// to avoid re-pinning every client when it expires.
+const selfSignedValidity = 10 * 365 * 24 * time.Hour
+
+// GetTLSPaths returns the paths of the self-signed certificate and key
+func GetTLSPaths() (certFile, keyFile string, err error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", "", err
+	}
+	dir := filepath.Join(homeDir, ".config", "baboon", "tls")
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", "", err
+	}
+	return filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem"), nil
+}
+
+// LoadOrCreateCertificate loads the certificate and key from certFile and
+// keyFile. If neither exists, a self-signed certificate for hosts (host
+// names or IP addresses) is generated and saved there first, so the
+// fingerprint stays the same across restarts.
+func LoadOrCreateCertificate(certFile, keyFile string, hosts []string) (tls.Certificate, error) {
+	_, certErr := os.Stat(certFile)
+	_, keyErr := os.Stat(keyFile)
+	if os.IsNotExist(certErr) && os.IsNotExist(keyErr) {
+		if err := writeSelfSigned(certFile, keyFile, hosts); err != nil {
+			return tls.Certificate{}, fmt.Errorf("failed to create certificate: %w", err)
+		}
+	}
+	return tls.LoadX509KeyPair(certFile, keyFile)
+}
+
+// writeSelfSigned generates a self-signed certificate and saves it with its key.
+func writeSelfSigned(certFile, keyFile string, hosts []string) error {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return err
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return err
+	}
+
+	template := x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{Organization: []string{"Baboon"}, CommonName: "baboon"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(selfSignedValidity),
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+	}
+	for _, host := range hosts {
+		if ip := net.ParseIP(host); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else if host != "" {
+			template.DNSNames = append(template.DNSNames, host)
+		}
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &key.PublicKey, key)
+	if err != nil {
+		return err
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return err
+	}
+
+	// The key is written first: a certificate without its key is unusable
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	if err := os.WriteFile(keyFile, keyPEM, 0600); err != nil {
+		return err
+	}
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	return os.WriteFile(certFile, certPEM, 0644)
+}
+
+// Fingerprint returns the SHA-256 fingerprint of a certificate's leaf, in
+// the colon-separated form printed by openssl x509 -fingerprint -sha256.
+func Fingerprint(cert tls.Certificate) string {
+	if len(cert.Certificate) == 0 {
+		return ""
+	}
+	return formatFingerprint(sha256.Sum256(cert.Certificate[0]))
+}
+
+func formatFingerprint(sum [sha256.Size]byte) string {
+	parts := make([]string, len(sum))
+	for i, b := range sum {
+		parts[i] = fmt.Sprintf("%02X", b)
+	}
+	return strings.Join(parts, ":")
+}
+
+// FingerprintFile returns the SHA-256 fingerprint of the first certificate
+// in a PEM file.
+func FingerprintFile(certFile string) (string, error) {
+	data, err := os.ReadFile(certFile)
+	if err != nil {
+		return "", err
+	}
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			return "", fmt.Errorf("%s: no certificate found", certFile)
+		}
+		if block.Type == "CERTIFICATE" {
+			return formatFingerprint(sha256.Sum256(block.Bytes)), nil
+		}
+	}
+}
+
+// PinnedTLSConfig returns a client TLS configuration that trusts only the
+// certificate with the given SHA-256 fingerprint, whoever signed it and
+// whatever host it names. The fingerprint may be written with or without
+// colons, in either case.
+func PinnedTLSConfig(fingerprint string) (*tls.Config, error) {
+	want, err := hex.DecodeString(strings.ReplaceAll(fingerprint, ":", ""))
+	if err != nil || len(want) != sha256.Size {
+		return nil, fmt.Errorf("invalid certificate fingerprint %q (expected a SHA-256 fingerprint)", fingerprint)
+	}
+	var pinned [sha256.Size]byte
+	copy(pinned[:], want)
+
+	return &tls.Config{
+		// Chain and host name verification are replaced by the pin below
+		InsecureSkipVerify: true,
+		VerifyPeerCertificate: func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+			if len(rawCerts) == 0 {
+				return errors.New("server presented no certificate")
+			}
+			if got := sha256.Sum256(rawCerts[0]); got != pinned {
+				return fmt.Errorf("server certificate fingerprint %s does not match the pinned %s",
+					formatFingerprint(got), formatFingerprint(pinned))
+			}
+			return nil
+		},
+		MinVersion: tls.VersionTLS12,
+	}, nil
+}
+
+// EnableTLS makes the server serve HTTPS with cert. It must be called
+// before Start.
+func (s *Server) EnableTLS(cert tls.Certificate) {
+	s.httpServer.TLSConfig = &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+}
+
+// usesTLS reports whether the server serves HTTPS.
+func (s *Server) usesTLS() bool {
+	return s.httpServer != nil && s.httpServer.TLSConfig != nil
+}
@@ -0,0 +1,214 @@
+package backend
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/timlinux/baboon/websocket"
+)
+
+// WebSocket protocol
+//
+// GET /api/sessions/{id}/ws upgrades to a WebSocket carrying the same
+// operations as the REST API for one session. Every message, in either
+// direction, is a WSMessage. Requests carry a client-chosen ID which the
+// reply echoes, with the same type and the REST response body as data.
+// Failed requests are answered with type "error". Requests are applied and
+// answered in the order they arrive.
+//
+// The protocol is versioned: clients offer the WSSubprotocol in the
+// handshake and set "v" on every message. Messages for another version are
+// rejected, so incompatible clients fail loudly rather than misbehave.
+//
+// Error replies carry the same message and code as the REST error body.
+const (
+	WSProtocolVersion = 1
+	WSSubprotocol     = "baboon.v1"
+)
+
+// WebSocket message types. Request data and reply data match the REST
+// request and response bodies of the same operation.
+const (
+	WSRound           = "round"            // POST /round
+	WSAbandon         = "abandon"          // POST /abandon
+	WSKeystroke       = "keystroke"        // POST /keystroke
+	WSBackspace       = "backspace"        // POST /backspace
+	WSSpace           = "space"            // POST /space
+	WSInput           = "input"            // POST /input
+	WSTiming          = "timing"           // POST /timing
+	WSState           = "state"            // GET /state
+	WSSessionStats    = "stats.session"    // GET /stats/session
+	WSHistoricalStats = "stats.historical" // GET /stats/historical
+	WSTrends          = "stats.trends"     // GET /stats/trends
+	WSSave            = "save"             // POST /save
+	WSError           = "error"            // Reply to a failed request
+)
+
+// WSMessage is the envelope for every WebSocket message
+type WSMessage struct {
+	Version int             `json:"v"`
+	ID      int64           `json:"id,omitempty"`
+	Type    string          `json:"type"`
+	Data    json.RawMessage `json:"data,omitempty"`
+	Error   string          `json:"error,omitempty"`
+	Code    string          `json:"code,omitempty"` // Error code (error replies only)
+}
+
+func (s *Server) handleWebSocket(w http.ResponseWriter, r *http.Request) {
+	sessionID := r.PathValue("id")
+	if _, exists := s.getSession(sessionID); !exists {
+		writeError(w, errSessionNotFound)
+		return
+	}
+
+	conn, err := websocket.Accept(w, r, WSSubprotocol)
+	if err != nil {
+		return
+	}
+	s.trackWebSocket(conn, true)
+	defer s.trackWebSocket(conn, false)
+	defer conn.Close()
+
+	logger := s.logger.With("session", sessionID)
+	logger.Debug("websocket connected")
+	defer logger.Debug("websocket closed")
+
+	for {
+		data, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+
+		out, _ := json.Marshal(s.wsReply(sessionID, data))
+		if err := conn.WriteMessage(out); err != nil {
+			return
+		}
+	}
+}
+
+// wsReply decodes and performs one WebSocket request, returning the reply.
+func (s *Server) wsReply(sessionID string, data []byte) WSMessage {
+	reply := WSMessage{Version: WSProtocolVersion, Type: WSError}
+
+	var req WSMessage
+	if err := json.Unmarshal(data, &req); err != nil {
+		reply.Error = "invalid message: " + err.Error()
+		reply.Code = CodeInvalidRequest
+		return reply
+	}
+	reply.ID = req.ID
+
+	result, err := s.handleWSMessage(sessionID, req)
+	if err == nil {
+		reply.Data, err = json.Marshal(result)
+	}
+	if err != nil {
+		var apiErr *APIError
+		if !errors.As(err, &apiErr) {
+			apiErr = &APIError{Message: err.Error(), Code: CodeInternalError}
+		}
+		reply.Error = apiErr.Message
+		reply.Code = apiErr.Code
+		return reply
+	}
+	reply.Type = req.Type
+	return reply
+}
+
+// handleWSMessage decodes one WebSocket request, performs it with the same
+// operation as the REST API and returns the reply data.
+func (s *Server) handleWSMessage(sessionID string, req WSMessage) (any, error) {
+	if req.Version != WSProtocolVersion {
+		return nil, invalidRequest("unsupported protocol version %d (server speaks %d)", req.Version, WSProtocolVersion)
+	}
+
+	decode := func(v any) error {
+		if len(req.Data) == 0 {
+			return nil
+		}
+		if err := json.Unmarshal(req.Data, v); err != nil {
+			return invalidRequest("invalid data: %v", err)
+		}
+		return nil
+	}
+
+	switch req.Type {
+	case WSRound:
+		return s.startRound(sessionID)
+
+	case WSAbandon:
+		var in AbandonRequest
+		if err := decode(&in); err != nil {
+			return nil, err
+		}
+		return s.abandonRound(sessionID, in)
+
+	case WSKeystroke:
+		var in KeystrokeRequest
+		if err := decode(&in); err != nil {
+			return nil, err
+		}
+		return s.processKeystroke(sessionID, in)
+
+	case WSBackspace:
+		return s.processBackspace(sessionID)
+
+	case WSSpace:
+		var in SpaceRequest
+		if err := decode(&in); err != nil {
+			return nil, err
+		}
+		return s.processSpace(sessionID, in)
+
+	case WSInput:
+		var in InputRequest
+		if err := decode(&in); err != nil {
+			return nil, err
+		}
+		return s.processInput(sessionID, in)
+
+	case WSTiming:
+		var in TimingRequest
+		if err := decode(&in); err != nil {
+			return nil, err
+		}
+		return s.submitTiming(sessionID, in)
+
+	case WSState:
+		return s.gameState(sessionID)
+
+	case WSSessionStats:
+		return s.sessionStats(sessionID)
+
+	case WSHistoricalStats:
+		return s.historicalStats(sessionID)
+
+	case WSTrends:
+		return s.trends(sessionID)
+
+	case WSSave:
+		return s.saveStats(sessionID)
+	}
+	return nil, invalidRequest("unknown message type %q", req.Type)
+}
+
+// trackWebSocket adds or removes an open connection.
+func (s *Server) trackWebSocket(conn *websocket.Conn, open bool) {
+	s.wsMu.Lock()
+	defer s.wsMu.Unlock()
+	if open {
+		s.wsConns[conn] = struct{}{}
+	} else {
+		delete(s.wsConns, conn)
+	}
+}
+
+// closeWebSockets closes every open WebSocket connection.
+func (s *Server) closeWebSockets() {
+	s.wsMu.Lock()
+	defer s.wsMu.Unlock()
+	for conn := range s.wsConns {
+		conn.Close()
+	}
+}
@@ -0,0 +1,680 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/timlinux/baboon/backend"
+	"github.com/timlinux/baboon/frontend"
+	"github.com/timlinux/baboon/settings"
+	"github.com/timlinux/baboon/stats"
+)
+
+// runStatus prints today's practice progress, suitable for shell prompts.
+//
+//	baboon status          # baboon: 3/5 rounds today, 7 day streak
+//	baboon status -short   # 3/5r 7d
+func runStatus(args []string) {
+	fs := flag.NewFlagSet("status", flag.ExitOnError)
+	short := fs.Bool("short", false, "Print a compact one-word summary")
+	profile := fs.String("profile", stats.DefaultProfile, "Statistics profile to report on")
+	fs.Parse(args)
+
+	s := loadSettings()
+	store, err := stats.OpenStore(s.Storage, *profile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	historical, err := store.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading stats: %v\n", err)
+		os.Exit(1)
+	}
+
+	now := time.Now()
+	today := historical.PracticeOn(now)
+	goal := s.DailyGoal
+	progress := goal.Progress(today.Rounds, today.Minutes())
+	streak := historical.CurrentStreak(now)
+
+	if *short {
+		unit := "r"
+		if goal.Unit == settings.GoalUnitMinutes {
+			unit = "m"
+		}
+		fmt.Printf("%.0f/%d%s %dd\n", progress, goal.Target, unit, streak)
+		return
+	}
+
+	status := "goal met"
+	if !goal.Met(today.Rounds, today.Minutes()) {
+		status = "time to practise"
+	}
+	fmt.Printf("baboon: %.0f/%d %s today (%s), %d day streak\n",
+		progress, goal.Target, goal.Unit, status, streak)
+}
+
+// runGoal shows or sets the daily practice goal.
+//
+//	baboon goal                # show the current goal
+//	baboon goal 5 rounds       # five rounds per day
+//	baboon goal 15 minutes     # fifteen minutes per day
+func runGoal(args []string) {
+	s := loadSettings()
+
+	if len(args) == 0 {
+		fmt.Printf("Daily goal: %d %s\n", s.DailyGoal.Target, s.DailyGoal.Unit)
+		return
+	}
+
+	target, err := strconv.Atoi(args[0])
+	if err != nil || target <= 0 {
+		fmt.Println("Error: goal must be a positive number, e.g. 'baboon goal 5 rounds'")
+		os.Exit(1)
+	}
+
+	unit := settings.GoalUnitRounds
+	if len(args) > 1 {
+		switch args[1] {
+		case "rounds", "round", "r":
+			unit = settings.GoalUnitRounds
+		case "minutes", "minute", "min", "m":
+			unit = settings.GoalUnitMinutes
+		default:
+			fmt.Printf("Error: unknown goal unit %q (use rounds or minutes)\n", args[1])
+			os.Exit(1)
+		}
+	}
+
+	s.DailyGoal = settings.DailyGoal{Unit: unit, Target: target}
+	if err := s.Save(); err != nil {
+		fmt.Printf("Error saving settings: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Daily goal set to %d %s\n", target, unit)
+}
+
+// runStats dispatches the stats maintenance subcommands.
+//
+//	baboon stats doctor        # report inconsistencies in saved stats
+//	baboon stats doctor -fix   # repair them (the old file is kept as a backup)
+//	baboon stats doctor -estimate-totals  # estimate totals missing from old files
+//	baboon stats migrate -to kv  # move stats to another storage backend
+func runStats(args []string) {
+	usage := "Usage: baboon stats doctor [-fix] [-estimate-totals] [-profile name]\n" +
+		"       baboon stats migrate -to json|kv [-profile name]"
+	if len(args) == 0 {
+		fmt.Println(usage)
+		os.Exit(1)
+	}
+	switch args[0] {
+	case "doctor":
+		runStatsDoctor(args[1:])
+	case "migrate":
+		runStatsMigrate(args[1:])
+	default:
+		fmt.Println(usage)
+		os.Exit(1)
+	}
+}
+
+// runStatsDoctor reports, and optionally repairs, inconsistencies in saved stats.
+func runStatsDoctor(args []string) {
+	fs := flag.NewFlagSet("stats doctor", flag.ExitOnError)
+	fix := fs.Bool("fix", false, "Repair the problems found")
+	estimate := fs.Bool("estimate-totals", false, "Estimate the totals of sessions recorded before they were saved from the personal bests")
+	profile := fs.String("profile", stats.DefaultProfile, "Statistics profile to check")
+	fs.Parse(args)
+
+	s := loadSettings()
+	store, err := stats.OpenStore(s.Storage, *profile)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Checking %s (schema version %d)\n", store.Location(), stats.SchemaVersion)
+
+	if *estimate {
+		n, err := stats.EstimateStoreTotals(store)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Estimated the totals of %d session(s) from the personal bests\n", n)
+	}
+
+	var issues []stats.Issue
+	if *fix {
+		issues, err = stats.RepairStore(store)
+	} else {
+		issues, err = stats.DiagnoseStore(store)
+	}
+	if errors.Is(err, stats.ErrKVCorrupt) {
+		fmt.Printf("Error: %v\n", err)
+		fmt.Printf("The file has not been changed. Restore an earlier copy (%s.1, %s.2, ...) or move it aside to start again.\n",
+			store.Location(), store.Location())
+		os.Exit(1)
+	}
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if len(issues) == 0 {
+		fmt.Println("No problems found")
+		return
+	}
+
+	repairable := 0
+	for _, issue := range issues {
+		fmt.Printf("  - %s\n", issue.Problem)
+		if issue.Repair == "" {
+			fmt.Println("      cannot be repaired automatically")
+			continue
+		}
+		repairable++
+		if *fix {
+			fmt.Printf("      repaired: %s\n", issue.Repair)
+		} else {
+			fmt.Printf("      fix: %s\n", issue.Repair)
+		}
+	}
+
+	if !*fix && repairable > 0 {
+		fmt.Printf("\n%d problem(s) can be repaired with 'baboon stats doctor -fix'\n", repairable)
+	}
+}
+
+// runStatsMigrate copies saved stats from the configured storage backend to
+// another one and switches the configuration over to it.
+func runStatsMigrate(args []string) {
+	fs := flag.NewFlagSet("stats migrate", flag.ExitOnError)
+	to := fs.String("to", "", "Storage backend to migrate to (json or kv)")
+	profile := fs.String("profile", stats.DefaultProfile, "Statistics profile to migrate")
+	force := fs.Bool("force", false, "Overwrite stats already stored in the target backend")
+	fs.Parse(args)
+
+	s := loadSettings()
+	if *to == "" || *to == s.Storage {
+		fmt.Printf("Error: -to must name a backend other than the current one (%s)\n", s.Storage)
+		os.Exit(1)
+	}
+
+	source, err := stats.OpenStore(s.Storage, *profile)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	target, err := stats.OpenStore(*to, *profile)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	historical, err := source.Load()
+	if err != nil {
+		fmt.Printf("Error loading %s: %v\n", source.Location(), err)
+		os.Exit(1)
+	}
+	existing, err := target.Load()
+	if err != nil {
+		fmt.Printf("Error reading %s: %v\n", target.Location(), err)
+		os.Exit(1)
+	}
+	if existing.TotalSessions > 0 && !*force {
+		fmt.Printf("Error: %s already holds %d sessions (use -force to overwrite)\n",
+			target.Location(), existing.TotalSessions)
+		os.Exit(1)
+	}
+
+	if err := target.Replace(historical); err != nil {
+		fmt.Printf("Error writing %s: %v\n", target.Location(), err)
+		os.Exit(1)
+	}
+
+	s.Storage = *to
+	if err := s.Save(); err != nil {
+		fmt.Printf("Error saving settings: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Copied %d sessions (%d rounds of history) from %s to %s\n",
+		historical.TotalSessions, len(historical.Rounds), source.Location(), target.Location())
+	fmt.Printf("Storage backend set to %s; %s has been left in place\n", *to, source.Location())
+}
+
+// runStress runs the concurrency stress harness against an in-process server
+// and reports keystroke latency while other sessions perform slow saves.
+//
+//	baboon stress                          # 8 typists, 2 savers, 5 seconds
+//	baboon stress -typists 32 -save-delay 500ms
+func runStress(args []string) {
+	fs := flag.NewFlagSet("stress", flag.ExitOnError)
+	typists := fs.Int("typists", 8, "Number of sessions typing concurrently")
+	savers := fs.Int("savers", 2, "Number of sessions saving stats in a loop")
+	duration := fs.Duration("duration", 5*time.Second, "How long to run")
+	saveDelay := fs.Duration("save-delay", 250*time.Millisecond, "Artificial latency added to every save")
+	fs.Parse(args)
+
+	dir, err := os.MkdirTemp("", "baboon-stress-")
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	defer os.RemoveAll(dir)
+
+	fmt.Printf("Running %d typists and %d savers for %s (save delay %s)...\n",
+		*typists, *savers, *duration, *saveDelay)
+	report, err := backend.RunStress(context.Background(), backend.StressConfig{
+		Typists:   *typists,
+		Savers:    *savers,
+		Duration:  *duration,
+		SaveDelay: *saveDelay,
+		Dir:       dir,
+	})
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.RemoveAll(dir)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Keystrokes: %d  Rounds: %d (%d recorded)  Saves: %d\n",
+		report.Keystrokes, report.Rounds, report.Recorded, report.Saves)
+	fmt.Printf("Keystroke latency: p50 %s  p99 %s  max %s\n", report.P50, report.P99, report.Max)
+	if report.Blocked() {
+		fmt.Println("FAIL: a keystroke waited as long as a save - sessions are blocking each other")
+		os.RemoveAll(dir)
+		os.Exit(1)
+	}
+	if lost := report.LostRounds(); lost != 0 {
+		fmt.Printf("FAIL: %d completed rounds are missing from the historical stats\n", lost)
+		os.RemoveAll(dir)
+		os.Exit(1)
+	}
+	fmt.Println("OK: typists were never blocked by other sessions' saves")
+}
+
+// runAPI dispatches the API description subcommands.
+//
+//	baboon api spec    # print the OpenAPI document
+//	baboon api check   # check the handlers and web client against it
+func runAPI(args []string) {
+	usage := "Usage: baboon api spec [-addr host:port]\n" +
+		"       baboon api check [-web path/to/api.js]"
+	if len(args) == 0 {
+		fmt.Println(usage)
+		os.Exit(1)
+	}
+	switch args[0] {
+	case "spec":
+		fs := flag.NewFlagSet("api spec", flag.ExitOnError)
+		addr := fs.String("addr", "127.0.0.1:8787", "Server address to list in the document")
+		fs.Parse(args[1:])
+		data, _ := json.MarshalIndent(backend.OpenAPI(*addr), "", "  ")
+		fmt.Println(string(data))
+	case "check":
+		runAPICheck(args[1:])
+	default:
+		fmt.Println(usage)
+		os.Exit(1)
+	}
+}
+
+// runAPICheck checks every route's responses against the OpenAPI document,
+// and that the web client only calls documented endpoints.
+func runAPICheck(args []string) {
+	fs := flag.NewFlagSet("api check", flag.ExitOnError)
+	web := fs.String("web", "web/src/api.js", "Web client to check (skipped if missing)")
+	fs.Parse(args)
+
+	dir, err := os.MkdirTemp("", "baboon-api-")
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	defer os.RemoveAll(dir)
+
+	problems, err := backend.CheckContract(dir)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.RemoveAll(dir)
+		os.Exit(1)
+	}
+	if source, err := os.ReadFile(*web); err == nil {
+		problems = append(problems, backend.CheckWebClient(string(source))...)
+	} else {
+		fmt.Printf("Skipping web client check: %v\n", err)
+	}
+
+	for _, problem := range problems {
+		fmt.Println(problem)
+	}
+	if len(problems) > 0 {
+		fmt.Printf("FAIL: %d disagreement(s) with the OpenAPI document\n", len(problems))
+		os.RemoveAll(dir)
+		os.Exit(1)
+	}
+	fmt.Println("OK: handlers and web client match the OpenAPI document")
+}
+
+// runToken prints a profile's API token, issuing one if it has none. Remote
+// clients pass it with -token; rotating it locks out anyone holding the old one.
+//
+//	baboon token                 # token for the default profile
+//	baboon token -profile work   # token for the "work" profile
+//	baboon token -rotate         # replace the token
+func runToken(args []string) {
+	fs := flag.NewFlagSet("token", flag.ExitOnError)
+	profile := fs.String("profile", stats.DefaultProfile, "Profile the token grants access to")
+	rotate := fs.Bool("rotate", false, "Replace the profile's token with a new one")
+	fs.Parse(args)
+
+	if !stats.ValidProfileName(*profile) {
+		fmt.Println("Error: profile names may only contain letters, digits, '-' and '_'")
+		os.Exit(1)
+	}
+	path, err := backend.GetTokensPath()
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	tokens, err := backend.LoadTokens(path)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	var token string
+	if *rotate {
+		token, err = tokens.Rotate(*profile)
+	} else {
+		token, err = tokens.Token(*profile)
+	}
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Println(token)
+}
+
+// runCert prints the SHA-256 fingerprint of the certificate served with
+// -tls, for remote clients to pin with -fingerprint.
+//
+//	baboon cert                    # the self-signed certificate
+//	baboon cert -file server.pem   # another certificate
+func runCert(args []string) {
+	fs := flag.NewFlagSet("cert", flag.ExitOnError)
+	file := fs.String("file", "", "Certificate to fingerprint (default: the self-signed certificate)")
+	fs.Parse(args)
+
+	certFile := *file
+	if certFile == "" {
+		var err error
+		if certFile, _, err = backend.GetTLSPaths(); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		if _, err := os.Stat(certFile); os.IsNotExist(err) {
+			fmt.Println("No certificate yet: start the server with -tls to create one")
+			os.Exit(1)
+		}
+	}
+
+	fingerprint, err := backend.FingerprintFile(certFile)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Println(fingerprint)
+}
+
+// runHook sends a sample round to the hooks configured in settings, so a
+// webhook receiver or command can be checked without playing a round.
+//
+//	baboon hook test                  # deliver a sample round
+//	baboon hook test -profile work    # with another profile name
+func runHook(args []string) {
+	usage := "Usage: baboon hook test [-profile name]"
+	if len(args) == 0 || args[0] != "test" {
+		fmt.Println(usage)
+		os.Exit(1)
+	}
+	fs := flag.NewFlagSet("hook test", flag.ExitOnError)
+	profile := fs.String("profile", stats.DefaultProfile, "Profile named in the sample round")
+	fs.Parse(args[1:])
+
+	s := loadSettings()
+	config := hookConfig(s)
+	if !config.Enabled() {
+		fmt.Println("No hooks configured: set hooks.webhook_url or hooks.command in ~/.config/baboon/settings.json")
+		os.Exit(1)
+	}
+
+	end := time.Now()
+	sample := backend.NewRoundCompletedEvent("test", *profile, &stats.Stats{
+		WordsCompleted: 30,
+		CorrectChars:   147,
+		IncorrectChars: 3,
+		StartTime:      end.Add(-36 * time.Second),
+		EndTime:        end,
+		Duration:       36 * time.Second,
+		WPM:            49,
+		RawWPM:         50,
+		NetWPM:         48,
+		Accuracy:       98,
+	})
+
+	logger, _ := backend.NewLogger(os.Stderr, "warn", backend.LogFormatText)
+	if err := backend.NewHooks(config, logger).Deliver(context.Background(), sample); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	if config.WebhookURL != "" {
+		fmt.Printf("Delivered to %s\n", config.WebhookURL)
+	}
+	if len(config.Command) > 0 {
+		fmt.Printf("Ran %s\n", strings.Join(config.Command, " "))
+	}
+}
+
+// runSimulate plays rounds with a synthetic typist and reports the stats the
+// engine calculated, to check WPM, accuracy and adaptive word selection
+// against a known typist. Rounds run in-process against throwaway stats
+// unless -url targets a server.
+//
+//	baboon simulate                                  # 10 rounds at 60 WPM
+//	baboon simulate -wpm 90 -errors 0.05 -rounds 50
+//	baboon simulate -finger-errors p=0.2,a=0.1       # weak little fingers
+//	baboon simulate -url http://host:8787 -typists 20 -realtime
+func runSimulate(args []string) {
+	model := backend.DefaultTypistModel()
+	fs := flag.NewFlagSet("simulate", flag.ExitOnError)
+	rounds := fs.Int("rounds", 10, "Rounds played by each typist")
+	typists := fs.Int("typists", 1, "Number of typists playing concurrently")
+	seed := fs.Int64("seed", time.Now().UnixNano(), "Random seed, for repeatable runs")
+	punctuation := fs.Bool("p", false, "Play in punctuation mode")
+	fs.Float64Var(&model.TargetWPM, "wpm", model.TargetWPM, "Key presses per minute / 5, counting advancing spaces and backspaces")
+	fs.StringVar(&model.Distribution, "distribution", model.Distribution, "Seek time distribution: lognormal, normal or constant")
+	fs.Float64Var(&model.Jitter, "jitter", model.Jitter, "Seek time standard deviation as a fraction of the mean")
+	fs.Float64Var(&model.ErrorRate, "errors", model.ErrorRate, "Chance of mistyping a letter")
+	fingerErrors := fs.String("finger-errors", "", "Per-finger error rates as finger=rate pairs; a finger is a key it types or its number, e.g. p=0.2,0=0.1")
+	fs.Float64Var(&model.CorrectionRate, "correct", model.CorrectionRate, "Chance of noticing and fixing a mistake")
+	fs.IntVar(&model.NoticeDelay, "notice", model.NoticeDelay, "Most letters typed before a mistake is noticed")
+	fs.Float64Var(&model.SameFingerSlowdown, "sfb", model.SameFingerSlowdown, "Seek time multiplier for same-finger bigrams")
+	fs.BoolVar(&model.Realtime, "realtime", false, "Type at the model's pace instead of as fast as possible")
+	serverURL := fs.String("url", "", "Play against the server at this URL instead of in-process")
+	transport := fs.String("transport", "http", "Client transport for -url: http or ws")
+	profile := fs.String("profile", "simulated", "Profile to play as with -url")
+	token := fs.String("token", os.Getenv("BABOON_TOKEN"), "API token for -url (default $BABOON_TOKEN, then the local token for -profile)")
+	fingerprint := fs.String("fingerprint", "", "SHA-256 fingerprint of the server certificate to trust")
+	fs.Parse(args)
+
+	if *fingerErrors != "" {
+		rates, err := parseFingerErrors(*fingerErrors)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		model.FingerErrorRates = rates
+	}
+	if err := model.Validate(); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	if *typists <= 0 || *rounds <= 0 {
+		fmt.Println("Error: -typists and -rounds must be positive")
+		os.Exit(1)
+	}
+
+	// A failed simulation exits non-zero, but only once the sessions and
+	// temporary profiles deferred below have been cleaned up
+	failed := false
+	defer func() {
+		if failed {
+			os.Exit(1)
+		}
+	}()
+
+	var apis []backend.GameAPI
+	if *serverURL == "" {
+		dir, err := os.MkdirTemp("", "baboon-simulate-")
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		defer os.RemoveAll(dir)
+
+		config := backend.DefaultConfig()
+		config.PunctuationMode = *punctuation
+		if apis, err = backend.SimulationEngines(*typists, config, dir); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.RemoveAll(dir)
+			os.Exit(1)
+		}
+	} else {
+		clients, err := simulationClients(*typists, *serverURL, *transport, *punctuation, *profile, *token, *fingerprint)
+		for _, client := range clients {
+			defer client.DeleteSession()
+			apis = append(apis, client)
+		}
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			for _, client := range clients {
+				client.DeleteSession()
+			}
+			os.Exit(1)
+		}
+	}
+
+	fmt.Printf("Simulating %d typist(s) x %d round(s) at %.0f WPM (seed %d)...\n",
+		*typists, *rounds, model.TargetWPM, *seed)
+	report, err := backend.Simulate(context.Background(), apis, backend.SimulationConfig{
+		Model:  model,
+		Rounds: *rounds,
+		Seed:   *seed,
+	})
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		failed = true
+		if report.Rounds == 0 {
+			return
+		}
+	}
+	printSimulationReport(report)
+}
+
+// parseFingerErrors parses -finger-errors pairs such as "p=0.2,0=0.1".
+func parseFingerErrors(value string) (map[int]float64, error) {
+	rates := make(map[int]float64)
+	for _, pair := range strings.Split(value, ",") {
+		key, rateText, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok {
+			return nil, fmt.Errorf("finger error %q is not finger=rate", pair)
+		}
+		rate, err := strconv.ParseFloat(rateText, 64)
+		if err != nil {
+			return nil, fmt.Errorf("finger error %q: rate is not a number", pair)
+		}
+
+		finger := -1
+		if n, err := strconv.Atoi(key); err == nil {
+			finger = n
+		} else if len(key) == 1 {
+			finger = stats.GetFinger(rune(key[0]))
+		}
+		if _, ok := stats.FingerNames[finger]; !ok {
+			return nil, fmt.Errorf("finger error %q: unknown finger %q", pair, key)
+		}
+		rates[finger] = rate
+	}
+	return rates, nil
+}
+
+// simulationClients creates a session on the server for each typist,
+// returning those created so far if one fails.
+func simulationClients(n int, baseURL, transport string, punctuationMode bool, profile, token, fingerprint string) ([]frontend.SessionClient, error) {
+	if !stats.ValidProfileName(profile) {
+		return nil, fmt.Errorf("profile names may only contain letters, digits, '-' and '_'")
+	}
+	if token == "" {
+		var err error
+		if token, err = loadTokens().Token(profile); err != nil {
+			return nil, fmt.Errorf("reading API token: %w", err)
+		}
+	}
+
+	var clients []frontend.SessionClient
+	for i := 0; i < n; i++ {
+		client, err := frontend.NewSessionClient(transport, baseURL, punctuationMode)
+		if err != nil {
+			return clients, err
+		}
+		if fingerprint != "" {
+			if err := client.SetPinnedCertificate(fingerprint); err != nil {
+				return clients, err
+			}
+		}
+		client.SetProfile(profile)
+		client.SetToken(token)
+		if i == 0 {
+			if err := client.WaitForServer(5 * time.Second); err != nil {
+				return clients, fmt.Errorf("could not connect to %s: %w", baseURL, err)
+			}
+		}
+		if err := client.CreateSession(); err != nil {
+			return clients, fmt.Errorf("creating session: %w", err)
+		}
+		clients = append(clients, client)
+	}
+	return clients, nil
+}
+
+// printSimulationReport prints what the engine made of the simulated typing.
+func printSimulationReport(report backend.SimulationReport) {
+	fmt.Printf("Rounds: %d  Keystrokes: %d  Backspaces: %d  (%.0f keys/s in %s)\n",
+		report.Rounds, report.Keystrokes, report.Backspaces,
+		report.KeystrokesPerSecond(), report.Wall.Round(time.Millisecond))
+	fmt.Printf("WPM: mean %.1f  min %.1f  max %.1f  (target %.0f)\n",
+		report.MeanWPM, report.MinWPM, report.MaxWPM, report.TargetWPM)
+	fmt.Printf("Raw WPM: %.1f  (expected %.1f)\n", report.MeanRawWPM, report.ExpectedRawWPM)
+	fmt.Printf("Accuracy: %.1f%%\n", report.MeanAccuracy)
+
+	if len(report.Fingers) == 0 {
+		return
+	}
+	fmt.Println()
+	fmt.Printf("%-10s %8s %10s %10s %14s\n", "Finger", "Errors", "Expected", "Measured", "Letter share")
+	for _, f := range report.Fingers {
+		fmt.Printf("%-10s %7.1f%% %9.1f%% %9.1f%% %6.1f%% -> %.1f%%\n",
+			stats.FingerNames[f.Finger], f.ErrorRate*100, f.ExpectedMiss*100, f.MeasuredMiss*100,
+			f.FirstShare*100, f.LastShare*100)
+	}
+}
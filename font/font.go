@@ -1,5 +1,10 @@
 package font
 
+import (
+	"strings"
+	"unicode/utf8"
+)
+
 // BlockLetter represents a single letter as block characters
 // Each letter is 6 lines tall and variable width
 // Uses smooth block elements: █ (full), ▀ (upper), ▄ (lower), ▌ (left), ▐ (right)
@@ -268,6 +273,126 @@ var BlockLetters = map[rune][]string{
 		"  ██  ",
 		"      ",
 	},
+	'\'': {
+		" ██ ",
+		" ▄▀ ",
+		"    ",
+		"    ",
+		"    ",
+		"    ",
+	},
+	'"': {
+		"██ ██",
+		"▀▀ ▀▀",
+		"     ",
+		"     ",
+		"     ",
+		"     ",
+	},
+	'-': {
+		"     ",
+		"     ",
+		"████ ",
+		"     ",
+		"     ",
+		"     ",
+	},
+	'(': {
+		"  ▄█",
+		" █▀ ",
+		"██  ",
+		" █▄ ",
+		"  ▀█",
+		"    ",
+	},
+	')': {
+		"█▄  ",
+		" ▀█ ",
+		"  ██",
+		" ▄█ ",
+		"█▀  ",
+		"    ",
+	},
+	'0': {
+		" ▄██▄ ",
+		"██  ██",
+		"██  ██",
+		"██  ██",
+		" ▀██▀ ",
+		"      ",
+	},
+	'1': {
+		"  ██  ",
+		"▄███  ",
+		"  ██  ",
+		"  ██  ",
+		"██████",
+		"      ",
+	},
+	'2': {
+		" ▄██▄ ",
+		"▀▀  ██",
+		"  ▄█▀ ",
+		"▄█▀   ",
+		"██████",
+		"      ",
+	},
+	'3': {
+		"█████▄",
+		"    ██",
+		" ████ ",
+		"    ██",
+		"█████▀",
+		"      ",
+	},
+	'4': {
+		"██  ██",
+		"██  ██",
+		"██████",
+		"    ██",
+		"    ██",
+		"      ",
+	},
+	'5': {
+		"██████",
+		"██    ",
+		"█████▄",
+		"    ██",
+		"█████▀",
+		"      ",
+	},
+	'6': {
+		" ▄███ ",
+		"██    ",
+		"█████▄",
+		"██  ██",
+		" ▀██▀ ",
+		"      ",
+	},
+	'7': {
+		"██████",
+		"    ██",
+		"   ██ ",
+		"  ██  ",
+		"  ██  ",
+		"      ",
+	},
+	'8': {
+		" ▄██▄ ",
+		"██  ██",
+		" ████ ",
+		"██  ██",
+		" ▀██▀ ",
+		"      ",
+	},
+	'9': {
+		" ▄██▄ ",
+		"██  ██",
+		" ▀████",
+		"    ██",
+		" ███▀ ",
+		"      ",
+	},
 }
 
 const LetterHeight = 6
@@ -283,6 +408,9 @@ func RenderWord(word string) [][]string {
 
 	for _, char := range word {
 		letter, ok := BlockLetters[char]
+		if !ok && char >= 'A' && char <= 'Z' {
+			letter, ok = capitalLetter(char)
+		}
 		if !ok {
 			// Use space for unknown characters
 			letter = BlockLetters[' ']
@@ -300,6 +428,20 @@ func RenderWord(word string) [][]string {
 	return lines
 }
 
+// capitalLetter returns the block letter for a capital. The letters are
+// already drawn in capitals, so a capital is its letter underlined on the
+// bottom line.
+func capitalLetter(char rune) ([]string, bool) {
+	letter, ok := BlockLetters[char-'A'+'a']
+	if !ok {
+		return nil, false
+	}
+	capital := append([]string(nil), letter...)
+	last := len(capital) - 1
+	capital[last] = strings.Repeat("▀", utf8.RuneCountInString(capital[last]))
+	return capital, true
+}
+
 // GetLetterWidth returns the width of a letter
 func GetLetterWidth(char rune) int {
 	letter, ok := BlockLetters[char]
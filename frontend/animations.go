@@ -9,7 +9,7 @@ import (
 
 // Animation configuration constants
 const (
-	NumAnimatedRows   = 25 // Number of rows to animate on results screen
+	NumAnimatedRows   = 30 // Number of rows to animate on results screen
 	AnimationInterval = 50 * time.Millisecond
 	StaggerDelay      = 3 // Frames between each row starting
 )
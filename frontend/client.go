@@ -2,9 +2,13 @@ package frontend
 
 import (
 	"bytes"
+	"crypto/tls"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/timlinux/baboon/backend"
@@ -17,23 +21,91 @@ type Client struct {
 	baseURL         string
 	sessionID       string
 	punctuationMode bool
+	quoteMode       bool
+	quoteLength     string
+	profile         string
+	token           string      // Bearer token sent with every request
+	tlsConfig       *tls.Config // Pinned server certificate, if any
 	httpClient      *http.Client
 
-	// Cached state to reduce HTTP calls during rendering
+	// Input waiting to be sent in the next batch
+	pending      []backend.InputEvent
+	pendingSince time.Time
+	sendFailures int       // Failed attempts to send the oldest buffered events
+	retryAt      time.Time // When a failed batch may be sent again
+
+	// Cached state to reduce HTTP calls during rendering. cachedState
+	// includes the predicted effect of any pending input.
 	cachedState      *backend.GameState
 	cachedSession    *stats.Stats
 	cachedHistorical *stats.HistoricalStats
+
+	// Outcome of the most recent request, and of the last input given up,
+	// reported by Err
+	errMu   sync.Mutex
+	lastErr error
+	lostErr error
 }
 
 // NewClient creates a new REST API client.
 func NewClient(baseURL string, punctuationMode bool) *Client {
-	return &Client{
+	c := &Client{
 		baseURL:         baseURL,
 		punctuationMode: punctuationMode,
-		httpClient: &http.Client{
-			Timeout: 5 * time.Second,
-		},
 	}
+	c.httpClient = &http.Client{
+		Timeout:   5 * time.Second,
+		Transport: bearerTransport{client: c, base: http.DefaultTransport},
+	}
+	return c
+}
+
+// SetProfile selects the stats profile used by sessions created after this call.
+func (c *Client) SetProfile(profile string) {
+	c.profile = profile
+}
+
+// SetQuoteMode selects quote mode, with passages of the given length
+// category (empty for any), for sessions created after this call.
+func (c *Client) SetQuoteMode(enabled bool, length string) {
+	c.quoteMode = enabled
+	c.quoteLength = length
+}
+
+// SetToken sets the bearer token sent with every request. It must belong
+// to the profile being played.
+func (c *Client) SetToken(token string) {
+	c.token = token
+}
+
+// SetPinnedCertificate makes the client trust only the server certificate
+// with the given SHA-256 fingerprint, such as a self-signed one, instead of
+// the system's certificate authorities.
+func (c *Client) SetPinnedCertificate(fingerprint string) error {
+	config, err := backend.PinnedTLSConfig(fingerprint)
+	if err != nil {
+		return err
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = config
+	c.tlsConfig = config
+	c.httpClient.Transport = bearerTransport{client: c, base: transport}
+	return nil
+}
+
+// bearerTransport adds the client's token to every request
+type bearerTransport struct {
+	client *Client
+	base   http.RoundTripper
+}
+
+func (t bearerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.client.token == "" {
+		return t.base.RoundTrip(req)
+	}
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer "+t.client.token)
+	return t.base.RoundTrip(req)
 }
 
 // GetSessionID returns the current session ID.
@@ -44,6 +116,7 @@ func (c *Client) GetSessionID() string {
 // WaitForServer waits until the server is ready, with a timeout.
 func (c *Client) WaitForServer(timeout time.Duration) error {
 	deadline := time.Now().Add(timeout)
+	var lastErr error
 	for time.Now().Before(deadline) {
 		resp, err := c.httpClient.Get(c.baseURL + "/api/health")
 		if err == nil && resp.StatusCode == http.StatusOK {
@@ -53,32 +126,118 @@ func (c *Client) WaitForServer(timeout time.Duration) error {
 		if resp != nil {
 			resp.Body.Close()
 		}
+		lastErr = err
 		time.Sleep(10 * time.Millisecond)
 	}
+	if lastErr != nil {
+		// Certificate errors, for example, will not go away by waiting
+		return fmt.Errorf("server not ready after %v: %w", timeout, lastErr)
+	}
 	return fmt.Errorf("server not ready after %v", timeout)
 }
 
-// CreateSession creates a new session on the server.
-func (c *Client) CreateSession() error {
-	body, _ := json.Marshal(map[string]bool{"punctuation_mode": c.punctuationMode})
-	req, _ := http.NewRequest("POST", c.baseURL+"/api/sessions", bytes.NewReader(body))
-	req.Header.Set("Content-Type", "application/json")
+// Err returns the error from the most recent failed request to the server,
+// or nil once a later change to the session succeeds. Reads that succeed do
+// not clear it, so that the error from a rejected keystroke is still shown
+// after the screen refreshes the game state. Input that was given up is
+// reported until later input is sent. Server-reported errors are
+// *backend.APIError values.
+func (c *Client) Err() error {
+	c.errMu.Lock()
+	defer c.errMu.Unlock()
+	if c.lastErr != nil {
+		return c.lastErr
+	}
+	return c.lostErr
+}
+
+// setLost records input given up, or clears it once later input is sent.
+func (c *Client) setLost(err error) {
+	c.errMu.Lock()
+	c.lostErr = err
+	c.errMu.Unlock()
+}
+
+// setErr records the outcome of a request for Err. A successful read does
+// not clear an earlier error.
+func (c *Client) setErr(err error, read bool) {
+	if err == nil && read {
+		return
+	}
+	c.errMu.Lock()
+	c.lastErr = err
+	c.errMu.Unlock()
+}
+
+// do sends a request with an optional JSON body. A response with the wanted
+// status is decoded into out, if it is not nil; any other status is returned
+// as the server's *backend.APIError. The outcome is recorded for Err.
+func (c *Client) do(method, url string, body, out any, want int) error {
+	err := c.send(method, url, body, out, want)
+	c.setErr(err, method == "GET")
+	return err
+}
+
+func (c *Client) send(method, url string, body, out any, want int) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, url, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return fmt.Errorf("failed to create session: %w", err)
+		return err
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusCreated {
-		return fmt.Errorf("failed to create session: status %d", resp.StatusCode)
+	if resp.StatusCode != want {
+		return decodeAPIError(resp)
+	}
+	if out == nil {
+		return nil
 	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("invalid response to %s %s: %w", method, strings.TrimPrefix(url, c.baseURL), err)
+	}
+	return nil
+}
 
-	var result struct {
-		SessionID string `json:"session_id"`
+// decodeAPIError reads the error from a failed response. A body that is not
+// an error envelope, such as a proxy's error page, is used as the message.
+func decodeAPIError(resp *http.Response) error {
+	apiErr := &backend.APIError{Status: resp.StatusCode}
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	if json.Unmarshal(data, apiErr) != nil || apiErr.Message == "" {
+		apiErr.Message = strings.TrimSpace(string(data))
+		if apiErr.Message == "" {
+			apiErr.Message = http.StatusText(resp.StatusCode)
+		}
 	}
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return fmt.Errorf("failed to decode session response: %w", err)
+	return apiErr
+}
+
+// CreateSession creates a new session on the server.
+func (c *Client) CreateSession() error {
+	req := backend.CreateSessionRequest{
+		PunctuationMode: c.punctuationMode,
+		QuoteMode:       c.quoteMode,
+		QuoteLength:     c.quoteLength,
+		Profile:         c.profile,
+	}
+	var result backend.CreateSessionResponse
+	if err := c.do("POST", c.baseURL+"/api/sessions", req, &result, http.StatusCreated); err != nil {
+		return fmt.Errorf("failed to create session: %w", err)
 	}
 
 	c.sessionID = result.SessionID
@@ -91,12 +250,10 @@ func (c *Client) DeleteSession() error {
 		return nil
 	}
 
-	req, _ := http.NewRequest("DELETE", c.baseURL+"/api/sessions/"+c.sessionID, nil)
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
+	c.flushAll()
+	if err := c.do("DELETE", c.sessionURL(), nil, nil, http.StatusNoContent); err != nil {
 		return err
 	}
-	resp.Body.Close()
 
 	c.sessionID = ""
 	return nil
@@ -113,82 +270,78 @@ func (c *Client) StartRound() {
 		return
 	}
 
-	req, _ := http.NewRequest("POST", c.sessionURL()+"/round", nil)
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
+	c.flushAll()
+	if c.do("POST", c.sessionURL()+"/round", nil, nil, http.StatusOK) != nil {
 		return
 	}
-	resp.Body.Close()
 
 	// Invalidate cache
 	c.cachedState = nil
 	c.cachedSession = nil
 }
 
+// AbandonRound gives up the round in progress via the REST API.
+func (c *Client) AbandonRound(keepPartial bool) bool {
+	if c.sessionID == "" {
+		return false
+	}
+
+	c.flushAll()
+	var result backend.AbandonResponse
+	err := c.do("POST", c.sessionURL()+"/abandon", backend.AbandonRequest{KeepPartial: keepPartial}, &result, http.StatusOK)
+	c.cachedState = nil
+	c.cachedSession = nil
+	return err == nil && result.Abandoned
+}
+
+// StartTimer starts the round's timer on the server, sent at once so the
+// server's state agrees with the countdown.
+func (c *Client) StartTimer() {
+	if c.sessionID == "" {
+		return
+	}
+	predictStart(c.state())
+	c.queue(backend.InputEvent{Type: backend.InputStart})
+	c.flush()
+}
+
+// PauseRound tells the server the round is paused, sending any buffered
+// input with it rather than leaving it waiting while the player is away.
+func (c *Client) PauseRound() {
+	if c.sessionID == "" {
+		return
+	}
+	c.queue(backend.InputEvent{Type: backend.InputPause})
+	c.flush()
+}
+
 // ProcessKeystroke sends a keystroke to the server (legacy, no timing).
 func (c *Client) ProcessKeystroke(char string) backend.KeystrokeResult {
 	return c.ProcessKeystrokeWithTiming(char, 0)
 }
 
-// ProcessKeystrokeWithTiming sends a keystroke with frontend-measured seek time.
+// ProcessKeystrokeWithTiming queues a keystroke with frontend-measured seek time.
+// The result is predicted locally; the keystroke is sent with the next batch.
 func (c *Client) ProcessKeystrokeWithTiming(char string, seekTimeMs int64) backend.KeystrokeResult {
 	if c.sessionID == "" {
 		return backend.KeystrokeResult{}
 	}
 
-	body, _ := json.Marshal(map[string]interface{}{
-		"char":         char,
-		"seek_time_ms": seekTimeMs,
-	})
-	req, _ := http.NewRequest("POST", c.sessionURL()+"/keystroke", bytes.NewReader(body))
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return backend.KeystrokeResult{}
-	}
-	defer resp.Body.Close()
-
-	var result struct {
-		IsCorrect    bool `json:"is_correct"`
-		TimerStarted bool `json:"timer_started"`
-		CharIndex    int  `json:"char_index"`
-	}
-	json.NewDecoder(resp.Body).Decode(&result)
-
-	// Invalidate cache
-	c.cachedState = nil
+	result := predictKeystroke(c.state(), char)
+	c.queue(backend.InputEvent{Type: backend.InputKeystroke, Char: char, SeekTimeMs: seekTimeMs})
 	c.cachedSession = nil
-
-	return backend.KeystrokeResult{
-		IsCorrect:    result.IsCorrect,
-		TimerStarted: result.TimerStarted,
-		CharIndex:    result.CharIndex,
-	}
+	return result
 }
 
-// ProcessBackspace sends a backspace to the server.
+// ProcessBackspace queues a backspace, predicting whether it removes a character.
 func (c *Client) ProcessBackspace() bool {
 	if c.sessionID == "" {
 		return false
 	}
 
-	req, _ := http.NewRequest("POST", c.sessionURL()+"/backspace", nil)
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return false
-	}
-	defer resp.Body.Close()
-
-	var result struct {
-		Removed bool `json:"removed"`
-	}
-	json.NewDecoder(resp.Body).Decode(&result)
-
-	// Invalidate cache
-	c.cachedState = nil
-
-	return result.Removed
+	removed := predictBackspace(c.state())
+	c.queue(backend.InputEvent{Type: backend.InputBackspace})
+	return removed
 }
 
 // ProcessSpace sends a space to the server (legacy, no timing).
@@ -196,38 +349,31 @@ func (c *Client) ProcessSpace() backend.SpaceResult {
 	return c.ProcessSpaceWithTiming(0)
 }
 
-// ProcessSpaceWithTiming sends a space with frontend-measured seek time.
+// ProcessSpaceWithTiming sends a space with frontend-measured seek time,
+// together with any buffered keystrokes, and returns the server's result.
 func (c *Client) ProcessSpaceWithTiming(seekTimeMs int64) backend.SpaceResult {
 	if c.sessionID == "" {
 		return backend.SpaceResult{}
 	}
 
-	body, _ := json.Marshal(map[string]int64{"seek_time_ms": seekTimeMs})
-	req, _ := http.NewRequest("POST", c.sessionURL()+"/space", bytes.NewReader(body))
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return backend.SpaceResult{}
-	}
-	defer resp.Body.Close()
+	predicted := predictSpace(c.state())
+	c.queue(backend.InputEvent{Type: backend.InputSpace, SeekTimeMs: seekTimeMs})
 
-	var result struct {
-		Advanced       bool `json:"advanced"`
-		RoundComplete  bool `json:"round_complete"`
-		TreatedAsError bool `json:"treated_as_error"`
-	}
-	json.NewDecoder(resp.Body).Decode(&result)
+	// Spaces end words, so send them straight away
+	results := c.flush()
 
 	// Invalidate cache
-	c.cachedState = nil
 	c.cachedSession = nil
 	c.cachedHistorical = nil
 
+	if len(results) == 0 {
+		return predicted
+	}
+	last := results[len(results)-1]
 	return backend.SpaceResult{
-		Advanced:       result.Advanced,
-		RoundComplete:  result.RoundComplete,
-		TreatedAsError: result.TreatedAsError,
+		Advanced:       last.Advanced,
+		RoundComplete:  last.RoundComplete,
+		TreatedAsError: last.TreatedAsError,
 	}
 }
 
@@ -237,73 +383,41 @@ func (c *Client) SubmitTiming(startTime, endTime time.Time, durationMs int64) {
 		return
 	}
 
-	body, _ := json.Marshal(map[string]int64{
-		"start_time_unix_ms": startTime.UnixMilli(),
-		"end_time_unix_ms":   endTime.UnixMilli(),
-		"duration_ms":        durationMs,
-	})
-	req, _ := http.NewRequest("POST", c.sessionURL()+"/timing", bytes.NewReader(body))
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
+	c.flushAll()
+	req := backend.TimingRequest{
+		StartTimeUnixMs: startTime.UnixMilli(),
+		EndTimeUnixMs:   endTime.UnixMilli(),
+		DurationMs:      durationMs,
+	}
+	if c.do("POST", c.sessionURL()+"/timing", req, nil, http.StatusOK) != nil {
 		return
 	}
-	resp.Body.Close()
 
 	// Invalidate cache
 	c.cachedSession = nil
 	c.cachedHistorical = nil
 }
 
-// GetGameState fetches the current game state from the server.
+// GetGameState returns the current game state. Between batches this is
+// predicted locally from the buffered input, so rendering needs no HTTP call.
 func (c *Client) GetGameState() backend.GameState {
 	if c.sessionID == "" {
 		return backend.GameState{}
 	}
 
-	resp, err := c.httpClient.Get(c.sessionURL() + "/state")
-	if err != nil {
-		if c.cachedState != nil {
-			return *c.cachedState
-		}
-		return backend.GameState{}
+	if len(c.pending) > 0 && time.Since(c.pendingSince) >= sendBufferDelay {
+		c.flush()
 	}
-	defer resp.Body.Close()
+	return *c.state()
+}
 
-	var state struct {
-		Words           []string `json:"words"`
-		CurrentWordIdx  int      `json:"current_word_idx"`
-		CurrentInput    string   `json:"current_input"`
-		TimerStarted    bool     `json:"timer_started"`
-		PunctuationMode bool     `json:"punctuation_mode"`
-		WordNumber      int      `json:"word_number"`
-		TotalWords      int      `json:"total_words"`
-		LiveWPM         float64  `json:"live_wpm"`
-		CurrentWord     string   `json:"current_word"`
-		PreviousWord    string   `json:"previous_word"`
-		NextWord        string   `json:"next_word"`
-		NextWords       []string `json:"next_words"`
-	}
-	json.NewDecoder(resp.Body).Decode(&state)
-
-	result := backend.GameState{
-		Words:           state.Words,
-		CurrentWordIdx:  state.CurrentWordIdx,
-		CurrentInput:    state.CurrentInput,
-		TimerStarted:    state.TimerStarted,
-		PunctuationMode: state.PunctuationMode,
-		WordNumber:      state.WordNumber,
-		TotalWords:      state.TotalWords,
-		LiveWPM:         state.LiveWPM,
-		CurrentWord:     state.CurrentWord,
-		PreviousWord:    state.PreviousWord,
-		NextWord:        state.NextWord,
-		NextWords:       state.NextWords,
-	}
-
-	c.cachedState = &result
-	return result
+// fetchGameState fetches the current game state from the server.
+func (c *Client) fetchGameState() (backend.GameState, error) {
+	var state backend.GameStateResponse
+	if err := c.do("GET", c.sessionURL()+"/state", nil, &state, http.StatusOK); err != nil {
+		return backend.GameState{}, err
+	}
+	return gameStateFromResponse(state), nil
 }
 
 // GetSessionStats fetches the session statistics from the server.
@@ -312,17 +426,14 @@ func (c *Client) GetSessionStats() *stats.Stats {
 		return &stats.Stats{}
 	}
 
-	resp, err := c.httpClient.Get(c.sessionURL() + "/stats/session")
-	if err != nil {
+	c.flush()
+	var sessionStats stats.Stats
+	if err := c.do("GET", c.sessionURL()+"/stats/session", nil, &sessionStats, http.StatusOK); err != nil {
 		if c.cachedSession != nil {
 			return c.cachedSession
 		}
 		return &stats.Stats{}
 	}
-	defer resp.Body.Close()
-
-	var sessionStats stats.Stats
-	json.NewDecoder(resp.Body).Decode(&sessionStats)
 
 	c.cachedSession = &sessionStats
 	return &sessionStats
@@ -334,43 +445,65 @@ func (c *Client) GetHistoricalStats() *stats.HistoricalStats {
 		return &stats.HistoricalStats{}
 	}
 
-	resp, err := c.httpClient.Get(c.sessionURL() + "/stats/historical")
-	if err != nil {
+	c.flush()
+	var historicalStats stats.HistoricalStats
+	if err := c.do("GET", c.sessionURL()+"/stats/historical", nil, &historicalStats, http.StatusOK); err != nil {
 		if c.cachedHistorical != nil {
 			return c.cachedHistorical
 		}
 		return &stats.HistoricalStats{}
 	}
-	defer resp.Body.Close()
+	initHistoricalMaps(&historicalStats)
 
-	var historicalStats stats.HistoricalStats
-	json.NewDecoder(resp.Body).Decode(&historicalStats)
+	c.cachedHistorical = &historicalStats
+	return &historicalStats
+}
 
-	// Ensure maps are initialised
-	if historicalStats.LetterAccuracy == nil {
-		historicalStats.LetterAccuracy = make(map[string]stats.LetterStats)
+// initHistoricalMaps ensures the maps of decoded historical stats are initialised.
+func initHistoricalMaps(h *stats.HistoricalStats) {
+	if h.LetterAccuracy == nil {
+		h.LetterAccuracy = make(map[string]stats.LetterStats)
 	}
-	if historicalStats.LetterSeekTime == nil {
-		historicalStats.LetterSeekTime = make(map[string]stats.LetterSeekStats)
+	if h.LetterSeekTime == nil {
+		h.LetterSeekTime = make(map[string]stats.LetterSeekStats)
 	}
-	if historicalStats.BigramSeekTime == nil {
-		historicalStats.BigramSeekTime = make(map[string]stats.BigramSeekStats)
+	if h.BigramSeekTime == nil {
+		h.BigramSeekTime = make(map[string]stats.BigramSeekStats)
 	}
-	if historicalStats.FingerStats == nil {
-		historicalStats.FingerStats = make(map[int]stats.FingerStat)
+	if h.FingerStats == nil {
+		h.FingerStats = make(map[int]stats.FingerStat)
 	}
-	if historicalStats.HandStats == nil {
-		historicalStats.HandStats = make(map[int]stats.HandStat)
+	if h.HandStats == nil {
+		h.HandStats = make(map[int]stats.HandStat)
 	}
-	if historicalStats.RowStats == nil {
-		historicalStats.RowStats = make(map[int]stats.RowStat)
+	if h.RowStats == nil {
+		h.RowStats = make(map[int]stats.RowStat)
 	}
-	if historicalStats.ErrorSubstitution == nil {
-		historicalStats.ErrorSubstitution = make(map[string]map[string]int)
+	if h.ErrorSubstitution == nil {
+		h.ErrorSubstitution = make(map[string]map[string]int)
 	}
+	if h.DailyPractice == nil {
+		h.DailyPractice = make(map[string]stats.DayPractice)
+	}
+}
 
-	c.cachedHistorical = &historicalStats
-	return &historicalStats
+// GetTrends fetches trend analysis from the server.
+func (c *Client) GetTrends() stats.TrendReport {
+	if c.sessionID == "" {
+		return stats.TrendReport{}
+	}
+
+	c.flush()
+	var trends stats.TrendReport
+	if c.do("GET", c.sessionURL()+"/stats/trends", nil, &trends, http.StatusOK) != nil {
+		return stats.TrendReport{}
+	}
+
+	if trends.LetterTrends == nil {
+		trends.LetterTrends = make(map[string]stats.LetterTrend)
+	}
+
+	return trends
 }
 
 // SaveStats saves the statistics via the server.
@@ -379,17 +512,10 @@ func (c *Client) SaveStats() error {
 		return fmt.Errorf("no session")
 	}
 
-	req, _ := http.NewRequest("POST", c.sessionURL()+"/save", nil)
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return err
+	c.flush()
+	if err := c.do("POST", c.sessionURL()+"/save", nil, nil, http.StatusOK); err != nil {
+		return fmt.Errorf("save failed: %w", err)
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("save failed with status %d", resp.StatusCode)
-	}
-
 	return nil
 }
 
@@ -0,0 +1,200 @@
+package frontend
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/timlinux/baboon/backend"
+)
+
+// Input is sent to the server in small batches rather than one request per
+// key. Keystrokes are buffered and their effect on the game state predicted
+// locally using the same rules as the engine; the batch is sent when a space
+// ends a word, when the buffer fills, when the oldest buffered key has waited
+// sendBufferDelay, or before any other request. The server's state then
+// replaces the prediction.
+//
+// A batch that fails to send because of a network error stays at the front
+// of the buffer and is sent again, waiting sendRetryDelay longer after each
+// failure, until sendAttempts have failed. Only then are its events given
+// up and the prediction replaced by the server's state.
+const (
+	sendBufferSize  = 16                     // Events buffered before sending
+	sendBufferDelay = 50 * time.Millisecond  // Longest an event waits to be sent
+	sendAttempts    = 3                      // Tries before buffered events are given up
+	sendRetryDelay  = 250 * time.Millisecond // Wait before the first retry, growing with each
+)
+
+// queue adds an event to the send buffer, sending the batch if it is full.
+func (c *Client) queue(event backend.InputEvent) {
+	if len(c.pending) == 0 {
+		c.pendingSince = time.Now()
+	}
+	c.pending = append(c.pending, event)
+	if len(c.pending) >= sendBufferSize {
+		c.flush()
+	}
+}
+
+// flush sends the buffered events and adopts the resulting server state.
+// It returns the per-event results, or nil if nothing was sent: the buffer
+// is empty, a retry is not yet due, or the request failed.
+func (c *Client) flush() []backend.InputEventResult {
+	if len(c.pending) == 0 || c.sessionID == "" || time.Now().Before(c.retryAt) {
+		return nil
+	}
+	events := c.pending
+	c.pending = nil
+
+	var result backend.InputResponse
+	err := c.do("POST", c.sessionURL()+"/input", backend.InputRequest{Events: events}, &result, http.StatusOK)
+	if err != nil {
+		c.sendFailed(events, err)
+		return nil
+	}
+	c.sendFailures = 0
+	c.retryAt = time.Time{}
+	c.setLost(nil)
+
+	state := gameStateFromResponse(result.State)
+	c.cachedState = &state
+	return result.Results
+}
+
+// flushAll sends the buffered events before a request that ends or replaces
+// the round, retrying until they are sent or given up, so no input is left
+// to be applied to the next round.
+func (c *Client) flushAll() {
+	for len(c.pending) > 0 && c.sessionID != "" {
+		time.Sleep(time.Until(c.retryAt))
+		c.flush()
+	}
+}
+
+// sendFailed keeps events that failed to send at the front of the buffer to
+// be sent again, unless the server rejected them, which would happen again,
+// or sendAttempts have failed. Events given up are reported by Err.
+func (c *Client) sendFailed(events []backend.InputEvent, err error) {
+	c.sendFailures++
+	var apiErr *backend.APIError
+	rejected := errors.As(err, &apiErr) && apiErr.Status < http.StatusInternalServerError
+	if !rejected && c.sendFailures < sendAttempts {
+		c.pending = events
+		c.retryAt = time.Now().Add(time.Duration(c.sendFailures) * sendRetryDelay)
+		return
+	}
+
+	c.sendFailures = 0
+	c.retryAt = time.Time{}
+	c.cachedState = nil // The prediction included the lost events
+	c.setLost(fmt.Errorf("%d input event(s) could not be sent and were lost: %w", len(events), err))
+}
+
+// state returns the predicted game state, fetching it from the server if
+// there is no prediction to build on.
+func (c *Client) state() *backend.GameState {
+	if c.cachedState == nil {
+		state, err := c.fetchGameState()
+		if err != nil {
+			return &backend.GameState{}
+		}
+		c.cachedState = &state
+	}
+	return c.cachedState
+}
+
+// predictKeystroke applies a keystroke to state as the engine would.
+func predictKeystroke(state *backend.GameState, char string) backend.KeystrokeResult {
+	if state.CurrentWordIdx >= len(state.Words) {
+		return backend.KeystrokeResult{}
+	}
+
+	word := state.Words[state.CurrentWordIdx]
+	inputIdx := len(state.CurrentInput)
+	result := backend.KeystrokeResult{CharIndex: inputIdx}
+
+	started := state.TimerStarted
+	if !started && state.CurrentWordIdx == 0 && inputIdx == 0 && len(word) > 0 && char == string(word[0]) {
+		started = true
+		result.TimerStarted = true
+	}
+
+	input := state.CurrentInput + char
+	result.IsCorrect = inputIdx < len(word) && input[inputIdx] == word[inputIdx]
+
+	rebuildState(state, state.CurrentWordIdx, input, started)
+	return result
+}
+
+// predictStart applies a countdown's start to state as the engine would.
+func predictStart(state *backend.GameState) {
+	if state.CurrentWordIdx >= len(state.Words) {
+		return
+	}
+	rebuildState(state, state.CurrentWordIdx, state.CurrentInput, true)
+}
+
+// predictBackspace applies a backspace to state as the engine would.
+func predictBackspace(state *backend.GameState) bool {
+	if len(state.CurrentInput) == 0 {
+		return false
+	}
+	input := state.CurrentInput[:len(state.CurrentInput)-1]
+	rebuildState(state, state.CurrentWordIdx, input, state.TimerStarted)
+	return true
+}
+
+// predictSpace applies a space to state as the engine would.
+func predictSpace(state *backend.GameState) backend.SpaceResult {
+	if state.CurrentWordIdx >= len(state.Words) {
+		return backend.SpaceResult{}
+	}
+
+	word := state.Words[state.CurrentWordIdx]
+	wordIdx, input := state.CurrentWordIdx, state.CurrentInput
+	var result backend.SpaceResult
+
+	switch {
+	case len(input) >= len(word):
+		wordIdx++
+		input = ""
+		result = backend.SpaceResult{Advanced: true, RoundComplete: wordIdx >= len(state.Words)}
+	case len(input) > 0 || state.TimerStarted:
+		input += " "
+		result = backend.SpaceResult{TreatedAsError: true}
+	default:
+		return result
+	}
+
+	rebuildState(state, wordIdx, input, state.TimerStarted)
+	return result
+}
+
+// rebuildState replaces state with the state at a new position in the same
+// round.
+func rebuildState(state *backend.GameState, wordIdx int, input string, started bool) {
+	source := state.QuoteSource
+	*state = backend.NewGameState(state.Words, wordIdx, input, started, state.PunctuationMode)
+	state.QuoteSource = source
+}
+
+// gameStateFromResponse converts the server's JSON game state.
+func gameStateFromResponse(state backend.GameStateResponse) backend.GameState {
+	return backend.GameState{
+		Words:           state.Words,
+		CurrentWordIdx:  state.CurrentWordIdx,
+		CurrentInput:    state.CurrentInput,
+		TimerStarted:    state.TimerStarted,
+		PunctuationMode: state.PunctuationMode,
+		WordNumber:      state.WordNumber,
+		TotalWords:      state.TotalWords,
+		LiveWPM:         state.LiveWPM,
+		CurrentWord:     state.CurrentWord,
+		PreviousWord:    state.PreviousWord,
+		NextWord:        state.NextWord,
+		NextWords:       state.NextWords,
+		QuoteSource:     state.QuoteSource,
+	}
+}
@@ -15,6 +15,7 @@ const (
 	StateTyping GameState = iota
 	StateResults
 	StateOptions
+	StateTrends
 )
 
 // tickMsg is sent periodically to update the WPM display
@@ -23,6 +24,12 @@ type tickMsg time.Time
 // animTickMsg is sent to update animations
 type animTickMsg time.Time
 
+// countdownMsg is sent once a second during a round's start countdown
+type countdownMsg time.Time
+
+// countdownFrom is where a countdown start counts down from
+const countdownFrom = 3
+
 // Model is the Bubble Tea model for the typing game
 type Model struct {
 	// Backend API
@@ -45,28 +52,46 @@ type Model struct {
 	lastKeyTime  time.Time
 	correctChars int // For live WPM calculation
 
+	// Pausing stops the clock: paused time is excluded from the round's
+	// duration, live WPM and the seek time of the next key
+	paused      bool
+	pausedAt    time.Time
+	pausedTotal time.Duration
+
+	// Seconds left before a countdown start reaches "go"
+	countdown int
+
 	// Settings
 	settings          *settings.Settings
-	optionsCursor     int  // Current selection in options menu
-	optionsFromTyping bool // Whether options was opened from typing screen
+	settingsErr       error // Why settings failed to load; they are then never saved
+	optionsCursor     int   // Current selection in options menu
+	optionsFromTyping bool  // Whether options was opened from typing screen
 }
 
 // NewModel creates a new Model with the given backend API
 func NewModel(api backend.GameAPI) Model {
-	// Load settings (use defaults if error)
-	s, _ := settings.Load()
-	return Model{
+	// Load settings, using defaults for this session if they can't be read
+	s, err := settings.Load()
+	m := Model{
 		api:              api,
 		state:            StateTyping,
 		renderer:         NewRenderer(80, 24), // Default size, will be updated
 		carouselAnimator: NewCarouselAnimator(),
 		lastWordIdx:      0,
 		settings:         s,
+		settingsErr:      err,
 	}
+	if s.StartMode == settings.StartModeCountdown {
+		m.countdown = countdownFrom
+	}
+	return m
 }
 
 // Init initializes the model and returns the initial command
 func (m Model) Init() tea.Cmd {
+	if m.countdown > 0 {
+		return tea.Batch(tickCmd(), countdownCmd())
+	}
 	return tickCmd()
 }
 
@@ -76,6 +101,23 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case tickMsg:
 		return m, tickCmd()
 
+	case countdownMsg:
+		if m.state != StateTyping || m.countdown == 0 {
+			return m, nil
+		}
+		m.countdown--
+		if m.countdown > 0 {
+			return m, countdownCmd()
+		}
+		// Go: the clock starts now, and the first key's seek time is the
+		// player's reaction time
+		now := time.Now()
+		m.timerStarted = true
+		m.startTime = now
+		m.lastKeyTime = now
+		m.api.StartTimer()
+		return m, nil
+
 	case animTickMsg:
 		// Handle results screen animations
 		if m.state == StateResults && m.animator != nil {
@@ -102,6 +144,8 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m.handleResultsInput(msg)
 		case StateOptions:
 			return m.handleOptionsInput(msg)
+		case StateTrends:
+			return m.handleTrendsInput(msg)
 		}
 
 	case tea.WindowSizeMsg:
@@ -113,36 +157,134 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
-// View renders the current state
+// errorReporter is implemented by API clients that report the outcome of
+// their most recent request to the server.
+type errorReporter interface {
+	Err() error
+}
+
+// View renders the current state, with any error from the server in place
+// of the footer
 func (m Model) View() string {
+	screen := m.screen()
+	if reporter, ok := m.api.(errorReporter); ok {
+		if err := reporter.Err(); err != nil {
+			return m.renderer.RenderErrorFooter(screen, err)
+		}
+	}
+	return screen
+}
+
+// screen renders the screen for the current state
+func (m Model) screen() string {
 	switch m.state {
 	case StateTyping:
 		gameState := m.api.GetGameState()
 		// Override live WPM with locally calculated value (avoids network latency)
 		if m.timerStarted && m.correctChars > 0 {
-			elapsed := time.Since(m.startTime).Minutes()
+			elapsed := m.elapsed(time.Now()).Minutes()
 			if elapsed > 0 {
 				gameState.LiveWPM = (float64(m.correctChars) / 5.0) / elapsed
 			}
 		}
 		gameState.TimerStarted = m.timerStarted
+		gameState.Paused = m.paused
+		gameState.Countdown = m.countdown
 		return m.renderer.RenderTypingScreenAnimated(gameState, m.carouselAnimator, m.settings)
 	case StateResults:
 		return m.renderer.RenderResultsScreen(
 			m.api.GetSessionStats(),
 			m.api.GetHistoricalStats(),
 			m.animator,
+			m.settings,
 		)
 	case StateOptions:
 		return m.renderer.RenderOptionsScreen(m.settings, m.optionsCursor)
+	case StateTrends:
+		return m.renderer.RenderTrendsScreen(m.api.GetTrends(), m.api.GetHistoricalStats(), m.settings)
 	}
 	return ""
 }
 
+// elapsed returns the typing time of the round so far, excluding pauses
+func (m Model) elapsed(now time.Time) time.Duration {
+	if m.paused {
+		now = m.pausedAt
+	}
+	return now.Sub(m.startTime) - m.pausedTotal
+}
+
+// pause stops the round's clock and tells the backend, so the seek time
+// of the first key after resuming is not recorded.
+func (m *Model) pause(now time.Time) {
+	m.paused = true
+	m.pausedAt = now
+	m.api.PauseRound()
+}
+
+// resume restarts the clock. The last key time moves forward by the length
+// of the pause, so the next seek time excludes it too.
+func (m *Model) resume(now time.Time) {
+	gap := now.Sub(m.pausedAt)
+	m.pausedTotal += gap
+	if !m.lastKeyTime.IsZero() {
+		m.lastKeyTime = m.lastKeyTime.Add(gap)
+	}
+	m.paused = false
+	m.pausedAt = time.Time{}
+}
+
+// abandon gives up a round in progress, keeping its partial stats if the
+// settings say so, and resets the local timing for the next round.
+func (m *Model) abandon() {
+	if !m.timerStarted {
+		return
+	}
+	if m.api.AbandonRound(m.settings.KeepPartialRounds) {
+		m.api.SaveStats()
+	}
+	m.timerStarted = false
+	m.startTime = time.Time{}
+	m.lastKeyTime = time.Time{}
+	m.correctChars = 0
+	m.paused = false
+	m.pausedTotal = 0
+}
+
+// startCountdown begins a new round's countdown if the round is started by
+// one, returning the command that drives it.
+func (m *Model) startCountdown() tea.Cmd {
+	if m.settings.StartMode != settings.StartModeCountdown {
+		return nil
+	}
+	m.countdown = countdownFrom
+	return countdownCmd()
+}
+
 // handleTypingInput processes keyboard input during typing
 func (m Model) handleTypingInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	now := time.Now()
 
+	// Keys are ignored until the countdown reaches "go"
+	if m.countdown > 0 {
+		switch msg.Type {
+		case tea.KeyCtrlC, tea.KeyEsc:
+			return m, tea.Quit
+		}
+		return m, nil
+	}
+
+	// While paused, any key other than Ctrl+C or ESC resumes without being typed
+	if m.paused {
+		switch msg.Type {
+		case tea.KeyCtrlC, tea.KeyEsc:
+			m.abandon()
+			return m, tea.Quit
+		}
+		m.resume(now)
+		return m, nil
+	}
+
 	// Check if this key should advance to the next word
 	isAdvanceKey := func() bool {
 		switch m.settings.AdvanceKey {
@@ -157,18 +299,29 @@ func (m Model) handleTypingInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	}
 
 	switch msg.Type {
-	case tea.KeyCtrlC, tea.KeyEsc:
+	case tea.KeyCtrlC:
+		m.abandon()
+		return m, tea.Quit
+
+	case tea.KeyEsc:
+		// Pause a round in progress rather than losing it
+		if m.timerStarted {
+			m.pause(now)
+			return m, nil
+		}
 		return m, tea.Quit
 
 	case tea.KeyTab:
-		// Restart the current round
+		// Restart with a new round, abandoning the current one if started
+		m.abandon()
 		m.api.StartRound()
 		m.carouselAnimator = NewCarouselAnimator()
 		m.timerStarted = false
 		m.startTime = time.Time{}
 		m.lastKeyTime = time.Time{}
 		m.correctChars = 0
-		return m, nil
+		m.pausedTotal = 0
+		return m, m.startCountdown()
 
 	case tea.KeySpace, tea.KeyEnter:
 		// Only process if this is the configured advance key
@@ -188,7 +341,7 @@ func (m Model) handleTypingInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			// Send final timing to backend
 			var durationMs int64
 			if m.timerStarted {
-				durationMs = now.Sub(m.startTime).Milliseconds()
+				durationMs = m.elapsed(now).Milliseconds()
 			}
 			m.api.SubmitTiming(m.startTime, now, durationMs)
 			m.api.SaveStats()
@@ -197,6 +350,7 @@ func (m Model) handleTypingInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			// Reset timing state
 			m.timerStarted = false
 			m.correctChars = 0
+			m.pausedTotal = 0
 			return m, animTickCmd()
 		} else if result.Advanced {
 			// Trigger carousel animation when moving to next word
@@ -261,6 +415,7 @@ func (m Model) handleResultsInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		m.startTime = time.Time{}
 		m.lastKeyTime = time.Time{}
 		m.correctChars = 0
+		return m, m.startCountdown()
 
 	case tea.KeyCtrlO:
 		// Open options with Ctrl+O
@@ -268,6 +423,38 @@ func (m Model) handleResultsInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		m.optionsCursor = 0
 		m.optionsFromTyping = false
 		return m, nil
+
+	case tea.KeyRunes:
+		// Open trends view with T
+		if string(msg.Runes) == "t" || string(msg.Runes) == "T" {
+			m.state = StateTrends
+			return m, nil
+		}
+	}
+
+	return m, nil
+}
+
+// handleTrendsInput processes keyboard input on the trends screen
+func (m Model) handleTrendsInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyCtrlC:
+		return m, tea.Quit
+
+	case tea.KeyEsc:
+		// Return to results screen
+		m.state = StateResults
+		return m, nil
+
+	case tea.KeyEnter:
+		// Start a new round straight from the trends screen
+		return m.handleResultsInput(msg)
+
+	case tea.KeyRunes:
+		if string(msg.Runes) == "t" || string(msg.Runes) == "T" {
+			m.state = StateResults
+			return m, nil
+		}
 	}
 
 	return m, nil
@@ -293,12 +480,12 @@ func (m Model) handleOptionsInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		if m.optionsCursor > 0 {
 			m.optionsCursor--
 		} else {
-			m.optionsCursor = 2 // Wrap to last option (3 options: 0, 1, 2)
+			m.optionsCursor = optionCount - 1 // Wrap to last option
 		}
 
 	case tea.KeyDown, tea.KeyTab:
 		// Move cursor down (wrap around)
-		if m.optionsCursor < 2 {
+		if m.optionsCursor < optionCount-1 {
 			m.optionsCursor++
 		} else {
 			m.optionsCursor = 0 // Wrap to first option
@@ -306,54 +493,49 @@ func (m Model) handleOptionsInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 
 	case tea.KeyEnter, tea.KeySpace:
 		// Select current option
-		m.settings.AdvanceKey = settings.AdvanceKey(m.optionsCursor)
-		// Save settings
-		_ = m.settings.Save()
-		// Return to previous screen
-		if m.optionsFromTyping {
-			m.state = StateTyping
-		} else {
-			m.state = StateResults
-		}
-		return m, nil
+		return m.selectOption(m.optionsCursor)
 
 	case tea.KeyRunes:
-		char := string(msg.Runes)
 		// Quick select with number keys
-		switch char {
-		case "1":
-			m.settings.AdvanceKey = settings.AdvanceKeySpace
-			_ = m.settings.Save()
-			if m.optionsFromTyping {
-				m.state = StateTyping
-			} else {
-				m.state = StateResults
-			}
-			return m, nil
-		case "2":
-			m.settings.AdvanceKey = settings.AdvanceKeyEnter
-			_ = m.settings.Save()
-			if m.optionsFromTyping {
-				m.state = StateTyping
-			} else {
-				m.state = StateResults
-			}
-			return m, nil
-		case "3":
-			m.settings.AdvanceKey = settings.AdvanceKeyEither
-			_ = m.settings.Save()
-			if m.optionsFromTyping {
-				m.state = StateTyping
-			} else {
-				m.state = StateResults
-			}
-			return m, nil
+		char := string(msg.Runes)
+		if len(char) == 1 && char[0] >= '1' && char[0] < '1'+optionCount {
+			return m.selectOption(int(char[0] - '1'))
 		}
 	}
 
 	return m, nil
 }
 
+// optionCount is the number of choices on the options screen: the three
+// advance keys followed by the two start modes
+const optionCount = 5
+
+// selectOption applies, saves and leaves the options screen with the choice
+// at the given index.
+func (m Model) selectOption(idx int) (tea.Model, tea.Cmd) {
+	if idx < 3 {
+		m.settings.AdvanceKey = settings.AdvanceKey(idx)
+	} else {
+		m.settings.StartMode = settings.StartMode(idx - 3)
+	}
+	// Never overwrite a settings file that could not be read: it may be
+	// damaged or written by a newer version
+	if m.settingsErr == nil {
+		_ = m.settings.Save()
+	}
+	// Return to previous screen, counting down now if the round hasn't
+	// started yet
+	if m.optionsFromTyping {
+		m.state = StateTyping
+		if !m.timerStarted {
+			return m, m.startCountdown()
+		}
+	} else {
+		m.state = StateResults
+	}
+	return m, nil
+}
+
 // tickCmd returns a command that sends tick messages
 func tickCmd() tea.Cmd {
 	return tea.Tick(100*time.Millisecond, func(t time.Time) tea.Msg {
@@ -361,6 +543,13 @@ func tickCmd() tea.Cmd {
 	})
 }
 
+// countdownCmd returns a command that advances the start countdown
+func countdownCmd() tea.Cmd {
+	return tea.Tick(time.Second, func(t time.Time) tea.Msg {
+		return countdownMsg(t)
+	})
+}
+
 // animTickCmd returns a command that sends animation tick messages
 func animTickCmd() tea.Cmd {
 	return tea.Tick(GetAnimationInterval(), func(t time.Time) tea.Msg {
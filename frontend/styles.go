@@ -26,6 +26,9 @@ var GradientColours = []string{
 	"190", "154", "118", "82", "46", "47",
 }
 
+// SparklineChars are the block characters used for sparklines, from lowest to highest
+var SparklineChars = []rune{'▁', '▂', '▃', '▄', '▅', '▆', '▇', '█'}
+
 // Styles holds all the lipgloss styles used in the application
 type Styles struct {
 	// Typing screen styles
@@ -0,0 +1,54 @@
+package frontend
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/timlinux/baboon/backend"
+)
+
+// Transports for talking to the backend
+const (
+	TransportHTTP      = "http" // REST requests, with input sent in batches
+	TransportWebSocket = "ws"   // One persistent WebSocket per session
+)
+
+// SessionClient is a backend.GameAPI that manages its own server session.
+type SessionClient interface {
+	backend.GameAPI
+
+	// SetProfile selects the stats profile used by sessions created after this call
+	SetProfile(profile string)
+
+	// SetQuoteMode selects quote mode, with passages of the given length
+	// category, for sessions created after this call
+	SetQuoteMode(enabled bool, length string)
+
+	// SetToken sets the bearer token sent with every request
+	SetToken(token string)
+
+	// SetPinnedCertificate trusts only the server certificate with this
+	// SHA-256 fingerprint
+	SetPinnedCertificate(fingerprint string) error
+
+	// WaitForServer waits until the server is ready, with a timeout
+	WaitForServer(timeout time.Duration) error
+
+	// CreateSession creates a new session on the server
+	CreateSession() error
+
+	// DeleteSession deletes the current session from the server
+	DeleteSession() error
+}
+
+// NewSessionClient creates a client for the server at baseURL using the
+// named transport. An empty transport selects TransportHTTP.
+func NewSessionClient(transport, baseURL string, punctuationMode bool) (SessionClient, error) {
+	switch transport {
+	case "", TransportHTTP:
+		return NewClient(baseURL, punctuationMode), nil
+	case TransportWebSocket:
+		return NewWSClient(baseURL, punctuationMode), nil
+	}
+	return nil, fmt.Errorf("unknown transport %q (use %s or %s)", transport, TransportHTTP, TransportWebSocket)
+}
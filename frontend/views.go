@@ -1,8 +1,11 @@
 package frontend
 
 import (
+	"errors"
 	"fmt"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/lipgloss"
 	"github.com/timlinux/baboon/backend"
@@ -44,8 +47,12 @@ func (r *Renderer) RenderTypingScreenAnimated(state backend.GameState, carousel
 		return "Loading..."
 	}
 
-	// Render current word using custom block font
+	// Render current word using custom block font; during a countdown start
+	// the remaining seconds take its place
 	letterLines := font.RenderWord(currentWord)
+	if state.Countdown > 0 {
+		letterLines = font.RenderWord(strconv.Itoa(state.Countdown))
+	}
 
 	// Build colored output for each line
 	coloredLines := make([]string, font.LetterHeight)
@@ -56,6 +63,23 @@ func (r *Renderer) RenderTypingScreenAnimated(state backend.GameState, carousel
 		for charIdx, letterLine := range letterLines[lineIdx] {
 			var style lipgloss.Style
 
+			if state.Countdown > 0 {
+				lineBuilder.WriteString(r.styles.Untyped.Render(letterLine))
+				if charIdx < len(letterLines[lineIdx])-1 {
+					lineBuilder.WriteString(" ")
+				}
+				continue
+			}
+
+			if state.Paused {
+				// Blur the word so the pause can't be used to read ahead
+				lineBuilder.WriteString(r.styles.Untyped.Render(blur(letterLine)))
+				if charIdx < len(letterLines[lineIdx])-1 {
+					lineBuilder.WriteString(" ")
+				}
+				continue
+			}
+
 			if charIdx < len(state.CurrentInput) {
 				// Character has been typed
 				if charIdx < len(currentWord) && state.CurrentInput[charIdx] == currentWord[charIdx] {
@@ -81,6 +105,12 @@ func (r *Renderer) RenderTypingScreenAnimated(state backend.GameState, carousel
 
 	// Progress indicator
 	progress := fmt.Sprintf("Word %d/%d", state.WordNumber, state.TotalWords)
+	switch {
+	case state.Countdown > 0:
+		progress = fmt.Sprintf("Get ready - Word %d/%d", state.WordNumber, state.TotalWords)
+	case state.Paused:
+		progress = fmt.Sprintf("PAUSED - Word %d/%d", state.WordNumber, state.TotalWords)
+	}
 
 	// Get animation values (default to fully visible if no animator)
 	prevOpacity := 0.5
@@ -94,7 +124,25 @@ func (r *Renderer) RenderTypingScreenAnimated(state backend.GameState, carousel
 
 	// Carousel style: Previous word above (animated opacity via colour intensity)
 	prevWordDisplay := ""
-	if state.PreviousWord != "" {
+	previousWord, nextWords := state.PreviousWord, state.NextWords
+	// Use NextWords if available, otherwise fall back to NextWord for backwards compatibility
+	if len(nextWords) == 0 && state.NextWord != "" {
+		nextWords = []string{state.NextWord}
+	}
+	if state.Countdown > 0 {
+		// The first word waits with the rest until the countdown ends
+		nextWords = append([]string{currentWord}, nextWords...)
+	}
+	if state.Paused {
+		previousWord = blur(previousWord)
+		blurred := make([]string, len(nextWords))
+		for i, word := range nextWords {
+			blurred[i] = blur(word)
+		}
+		nextWords = blurred
+	}
+
+	if previousWord != "" {
 		// Map opacity to greyscale colour (232-255 range in 256-colour palette)
 		// Lower opacity = darker colour
 		greyLevel := 232 + int(prevOpacity*23) // 232 (darkest) to 255 (lightest)
@@ -104,16 +152,11 @@ func (r *Renderer) RenderTypingScreenAnimated(state backend.GameState, carousel
 		prevStyle := lipgloss.NewStyle().
 			Foreground(lipgloss.Color(fmt.Sprintf("%d", greyLevel))).
 			Italic(true)
-		prevWordDisplay = prevStyle.Render("· · · " + state.PreviousWord + " · · ·")
+		prevWordDisplay = prevStyle.Render("· · · " + previousWord + " · · ·")
 	}
 
 	// Carousel style: Next words below (up to 3, with decreasing opacity)
 	var nextWordsDisplay []string
-	// Use NextWords if available, otherwise fall back to NextWord for backwards compatibility
-	nextWords := state.NextWords
-	if len(nextWords) == 0 && state.NextWord != "" {
-		nextWords = []string{state.NextWord}
-	}
 	for i, word := range nextWords {
 		// Decrease opacity for words further ahead
 		wordOpacity := nextOpacity * (1.0 - float64(i)*0.2)
@@ -193,6 +236,12 @@ func (r *Renderer) RenderTypingScreenAnimated(state backend.GameState, carousel
 	carouselElements = append(carouselElements, "")
 	carouselElements = append(carouselElements, wpmBar)
 
+	// Attribution for quote mode
+	if state.QuoteSource != "" {
+		carouselElements = append(carouselElements, "")
+		carouselElements = append(carouselElements, r.styles.Help.Render("— "+state.QuoteSource))
+	}
+
 	// Center the main content horizontally
 	mainContent := lipgloss.JoinVertical(
 		lipgloss.Center,
@@ -212,10 +261,15 @@ func (r *Renderer) RenderTypingScreenAnimated(state backend.GameState, carousel
 	if s != nil {
 		advanceKeyHint = s.AdvanceKey.KeyHint()
 	}
-	if !state.TimerStarted {
+	switch {
+	case state.Countdown > 0:
+		helpText = "Get ready - the round starts when the countdown ends | ESC to quit"
+	case state.Paused:
+		helpText = "Paused - press any key to resume | ESC to quit"
+	case !state.TimerStarted:
 		helpText = "Type the first letter to start | Tab to restart | Ctrl+O for options | ESC to quit"
-	} else {
-		helpText = fmt.Sprintf("Type the word, then press %s to continue | Tab to restart | ESC to quit", advanceKeyHint)
+	default:
+		helpText = fmt.Sprintf("Type the word, then press %s to continue | Tab to restart | ESC to pause", advanceKeyHint)
 	}
 	footer := lipgloss.PlaceHorizontal(r.width, lipgloss.Center, r.styles.Help.Render(helpText))
 
@@ -259,6 +313,16 @@ func (r *Renderer) RenderTypingScreenAnimated(state backend.GameState, carousel
 	return fullContent.String()
 }
 
+// blur hides text behind shading, keeping its shape and length.
+func blur(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r == ' ' {
+			return r
+		}
+		return '░'
+	}, s)
+}
+
 // renderWPMBar creates a beautiful gradient progress bar for WPM
 func (r *Renderer) renderWPMBar(wpm float64) string {
 	const maxWPM = 120.0
@@ -321,6 +385,7 @@ func (r *Renderer) RenderResultsScreen(
 	session *stats.Stats,
 	historical *stats.HistoricalStats,
 	animator *Animator,
+	s *settings.Settings,
 ) string {
 	const labelWidth = 18
 	const valueWidth = 8
@@ -331,15 +396,27 @@ func (r *Renderer) RenderResultsScreen(
 
 	title := r.styles.Title.Render("Round Complete!")
 
-	// Check for new bests
-	isNewBestWPM := session.WPM >= historical.BestWPM
+	// Check for new bests. A quote's time is only compared with earlier
+	// rounds of the same quote.
+	bestTimeLabel, bestTime := "Time best:", historical.BestTime
 	isNewBestTime := historical.TotalSessions == 1 || session.Duration.Seconds() <= historical.BestTime
+	if session.QuoteID != 0 {
+		quoteBest := historical.QuoteBests[session.QuoteID]
+		bestTimeLabel, bestTime = "Quote best:", quoteBest.BestTime
+		isNewBestTime = quoteBest.Rounds == 1 || session.Duration.Seconds() <= quoteBest.BestTime
+	}
+	isNewBestWPM := session.WPM >= historical.BestWPM
 	isNewBestAccuracy := session.Accuracy >= historical.BestAccuracy
 
 	// Build animated rows
 	animIdx := 0
 	var statsLines []string
 
+	// Attribution for quote mode
+	if session.QuoteSource != "" {
+		statsLines = append(statsLines, r.styles.Help.Render("— "+session.QuoteSource))
+	}
+
 	// WPM section
 	statsLines = append(statsLines, "")
 	statsLines = append(statsLines, animator.ApplyAnimation(r.formatStatRow(
@@ -357,6 +434,10 @@ func (r *Renderer) RenderResultsScreen(
 		r.renderStatBar(historical.AverageWPM(), maxWPMDisplay, barWidth, false),
 		labelWidth, valueWidth), animIdx))
 	animIdx++
+	statsLines = append(statsLines, animator.ApplyAnimation(r.renderSpeedBreakdown(session, labelWidth), animIdx))
+	animIdx++
+	statsLines = append(statsLines, animator.ApplyAnimation(r.renderSpeedCurve(session, labelWidth), animIdx))
+	animIdx++
 
 	// Time section
 	statsLines = append(statsLines, "")
@@ -366,8 +447,8 @@ func (r *Renderer) RenderResultsScreen(
 		labelWidth, valueWidth), animIdx))
 	animIdx++
 	statsLines = append(statsLines, animator.ApplyAnimation(r.formatStatRow(
-		"Time best:", fmt.Sprintf("%.1fs", historical.BestTime),
-		r.renderTimeBar(historical.BestTime, maxTimeDisplay, barWidth, false),
+		bestTimeLabel, fmt.Sprintf("%.1fs", bestTime),
+		r.renderTimeBar(bestTime, maxTimeDisplay, barWidth, false),
 		labelWidth, valueWidth), animIdx))
 	animIdx++
 	statsLines = append(statsLines, animator.ApplyAnimation(r.formatStatRow(
@@ -394,11 +475,23 @@ func (r *Renderer) RenderResultsScreen(
 		labelWidth, valueWidth), animIdx))
 	animIdx++
 
+	// Correction efficiency section
+	statsLines = append(statsLines, "")
+	statsLines = append(statsLines, animator.ApplyAnimation(r.renderCorrections(session, labelWidth), animIdx))
+	animIdx++
+	statsLines = append(statsLines, animator.ApplyAnimation(r.renderCorrectionEfficiency(session, historical, labelWidth), animIdx))
+	animIdx++
+
 	// Sessions
 	statsLines = append(statsLines, "")
-	statsLines = append(statsLines, animator.ApplyAnimation(
-		r.styles.SessionLabel.Render("Total sessions:")+" "+r.styles.SessionValue.Render(fmt.Sprintf("%d", historical.TotalSessions)),
-		animIdx))
+	sessionsLine := r.styles.SessionLabel.Render("Total sessions:") + " " + r.styles.SessionValue.Render(fmt.Sprintf("%d", historical.TotalSessions))
+	if historical.AbandonedRounds > 0 {
+		sessionsLine += "  " + r.styles.SessionLabel.Render("Abandoned:") + " " +
+			r.styles.SessionValue.Render(fmt.Sprintf("%d (%.0f%%)", historical.AbandonedRounds, historical.AbandonRate()))
+	}
+	statsLines = append(statsLines, animator.ApplyAnimation(sessionsLine, animIdx))
+	animIdx++
+	statsLines = append(statsLines, animator.ApplyAnimation(r.renderDailyGoal(historical, s, labelWidth, barWidth), animIdx))
 	animIdx++
 
 	// Typing theory stats
@@ -413,6 +506,10 @@ func (r *Renderer) RenderResultsScreen(
 	animIdx++
 	statsLines = append(statsLines, animator.ApplyAnimation(r.renderSFBStats(session, historical, labelWidth), animIdx))
 	animIdx++
+	if session.ReactionTimeMs > 0 || historical.ReactionStats.Count > 0 {
+		statsLines = append(statsLines, animator.ApplyAnimation(r.renderReactionStats(session, historical, labelWidth), animIdx))
+		animIdx++
+	}
 	statsLines = append(statsLines, animator.ApplyAnimation(r.renderTopErrors(historical, labelWidth), animIdx))
 	animIdx++
 
@@ -452,7 +549,7 @@ func (r *Renderer) RenderResultsScreen(
 
 	// Fixed footer at bottom
 	footer := lipgloss.PlaceHorizontal(r.width, lipgloss.Center,
-		r.styles.Help.Render("Press ENTER for a new round | Ctrl+O for options | ESC to quit"))
+		r.styles.Help.Render("Press ENTER for a new round | T for trends | Ctrl+O for options | ESC to quit"))
 
 	// Calculate heights
 	headerHeight := 1
@@ -589,6 +686,146 @@ func (r *Renderer) renderTimeBar(value, maxValue float64, width int, isNewBest b
 	return bar.String()
 }
 
+// renderSpeedBreakdown renders raw WPM, net WPM and the corrected error count
+func (r *Renderer) renderSpeedBreakdown(session *stats.Stats, labelWidth int) string {
+	var row strings.Builder
+	labelStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color(ColourLabel)).
+		Width(labelWidth).
+		Align(lipgloss.Right)
+	valueStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(ColourValue))
+
+	row.WriteString(labelStyle.Render("Raw / Net WPM:"))
+	row.WriteString(" ")
+	row.WriteString(valueStyle.Render(fmt.Sprintf("%.1f / %.1f", session.RawWPM, session.NetWPM)))
+
+	// Colour the corrected count by how much typing was wasted on fixes
+	var wasted float64
+	if session.TotalCharacters > 0 {
+		wasted = float64(session.CorrectedErrors) / float64(session.TotalCharacters) * 100
+	}
+	style := lipgloss.NewStyle().Foreground(lipgloss.Color(GetAccuracyColour(100 - wasted*5)))
+	row.WriteString(valueStyle.Render("  Corrected: "))
+	row.WriteString(style.Render(fmt.Sprintf("%d", session.CorrectedErrors)))
+	row.WriteString(valueStyle.Render(fmt.Sprintf("  Uncorrected: %d", session.UncorrectedErrors)))
+
+	return row.String()
+}
+
+// renderSpeedCurve renders the per-word WPM series as a coloured sparkline
+func (r *Renderer) renderSpeedCurve(session *stats.Stats, labelWidth int) string {
+	var row strings.Builder
+	labelStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color(ColourLabel)).
+		Width(labelWidth).
+		Align(lipgloss.Right)
+
+	row.WriteString(labelStyle.Render("Speed curve:"))
+	row.WriteString(" ")
+
+	if len(session.WordWPM) == 0 {
+		row.WriteString(r.styles.CountStyle.Render("none"))
+		return row.String()
+	}
+
+	sparkline, minWPM, maxWPM := r.renderSparkline(session.WordWPM)
+	row.WriteString(sparkline)
+	row.WriteString(r.styles.CountStyle.Render(fmt.Sprintf(" %.0f-%.0f", minWPM, maxWPM)))
+
+	return row.String()
+}
+
+// renderSparkline renders a series as coloured block characters scaled to the
+// series' own min/max so variation is visible. Returns the sparkline and the range.
+func (r *Renderer) renderSparkline(values []float64) (string, float64, float64) {
+	if len(values) == 0 {
+		return "", 0, 0
+	}
+
+	minVal, maxVal := values[0], values[0]
+	for _, v := range values {
+		if v < minVal {
+			minVal = v
+		}
+		if v > maxVal {
+			maxVal = v
+		}
+	}
+
+	var line strings.Builder
+	for _, v := range values {
+		level := 0
+		if maxVal > minVal {
+			level = int((v - minVal) / (maxVal - minVal) * float64(len(SparklineChars)-1))
+		}
+		style := lipgloss.NewStyle().Foreground(lipgloss.Color(GetRelativeColour(v, minVal, maxVal)))
+		line.WriteString(style.Render(string(SparklineChars[level])))
+	}
+
+	return line.String(), minVal, maxVal
+}
+
+// renderCorrections renders this round's backspace and error correction counts
+func (r *Renderer) renderCorrections(session *stats.Stats, labelWidth int) string {
+	var row strings.Builder
+	labelStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color(ColourLabel)).
+		Width(labelWidth).
+		Align(lipgloss.Right)
+	valueStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(ColourValue))
+
+	row.WriteString(labelStyle.Render("Corrections:"))
+	row.WriteString(" ")
+
+	corrections := session.Corrections()
+	totalErrors := corrections.CorrectedErrors + corrections.UncorrectedErrors
+	row.WriteString(valueStyle.Render(fmt.Sprintf("%d backspaces  %d/%d errors fixed  %d retyped",
+		corrections.Backspaces, corrections.CorrectedErrors, totalErrors, corrections.CharsRetyped)))
+
+	return row.String()
+}
+
+// renderCorrectionEfficiency renders how efficiently errors were corrected,
+// compared with the historical average
+func (r *Renderer) renderCorrectionEfficiency(session *stats.Stats, historical *stats.HistoricalStats, labelWidth int) string {
+	var row strings.Builder
+	labelStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color(ColourLabel)).
+		Width(labelWidth).
+		Align(lipgloss.Right)
+	valueStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(ColourValue))
+
+	row.WriteString(labelStyle.Render("Correction eff.:"))
+	row.WriteString(" ")
+
+	corrections := session.Corrections()
+	if corrections.Backspaces == 0 && corrections.UncorrectedErrors == 0 {
+		row.WriteString(valueStyle.Render("no corrections needed"))
+		return row.String()
+	}
+
+	if corrections.Backspaces > 0 {
+		efficiency := corrections.Efficiency()
+		style := lipgloss.NewStyle().Foreground(lipgloss.Color(GetAccuracyColour(efficiency)))
+		row.WriteString(style.Render(fmt.Sprintf("%.0f%%", efficiency)))
+		if historical.CorrectionStats.Backspaces > 0 {
+			row.WriteString(valueStyle.Render(fmt.Sprintf(" (avg: %.0f%%)", historical.CorrectionStats.Efficiency())))
+		}
+		row.WriteString("  ")
+	}
+
+	rate := corrections.CorrectionRate()
+	rateStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(GetAccuracyColour(rate)))
+	row.WriteString(valueStyle.Render("Fixed: "))
+	row.WriteString(rateStyle.Render(fmt.Sprintf("%.0f%%", rate)))
+	histTotal := historical.CorrectionStats.CorrectedErrors + historical.CorrectionStats.UncorrectedErrors
+	if histTotal > 0 {
+		row.WriteString(valueStyle.Render(fmt.Sprintf(" (avg: %.0f%%)", historical.CorrectionStats.CorrectionRate())))
+	}
+
+	return row.String()
+}
+
 // renderLetterHeaderRow renders a row of 26 letters as column headers
 func (r *Renderer) renderLetterHeaderRow() string {
 	var row strings.Builder
@@ -885,7 +1122,7 @@ func (r *Renderer) renderHandStats(historical *stats.HistoricalStats, labelWidth
 	return row.String()
 }
 
-// renderRhythmStats renders typing rhythm consistency
+// renderRhythmStats renders typing rhythm consistency and seek time percentiles
 func (r *Renderer) renderRhythmStats(session *stats.Stats, historical *stats.HistoricalStats, labelWidth int) string {
 	var row strings.Builder
 	labelStyle := lipgloss.NewStyle().
@@ -897,20 +1134,26 @@ func (r *Renderer) renderRhythmStats(session *stats.Stats, historical *stats.His
 	row.WriteString(labelStyle.Render("Rhythm:"))
 	row.WriteString(" ")
 
-	sessionStdDev := session.CalculateRhythmStdDev()
-	histStdDev := historical.RhythmStats.StdDev()
-
-	maxStdDev := 200.0
-	consistency := 100.0 - (sessionStdDev/maxStdDev)*100
-	if consistency < 0 {
-		consistency = 0
-	}
+	// Coefficient of variation based consistency (100% = perfectly even)
+	consistency := session.Consistency
 	colour := GetAccuracyColour(consistency)
 	style := lipgloss.NewStyle().Foreground(lipgloss.Color(colour))
 
-	row.WriteString(valueStyle.Render("StdDev: "))
-	row.WriteString(style.Render(fmt.Sprintf("%.0fms", sessionStdDev)))
-	row.WriteString(valueStyle.Render(fmt.Sprintf(" (avg: %.0fms)", histStdDev)))
+	row.WriteString(valueStyle.Render("Consistency: "))
+	row.WriteString(style.Render(fmt.Sprintf("%.0f%%", consistency)))
+	if historical.RhythmStats.Count > 0 {
+		row.WriteString(valueStyle.Render(fmt.Sprintf(" (avg: %.0f%%)", historical.RhythmStats.Consistency())))
+	}
+
+	// Percentiles are robust to the odd long pause, unlike the mean
+	row.WriteString(valueStyle.Render(fmt.Sprintf("  p50/p90/p99: %.0f/%.0f/%.0fms",
+		session.SeekTimeP50, session.SeekTimeP90, session.SeekTimeP99)))
+	if historical.RhythmStats.Histogram.Total > 0 {
+		row.WriteString(valueStyle.Render(fmt.Sprintf(" (avg: %.0f/%.0f/%.0fms)",
+			historical.RhythmStats.Percentile(50),
+			historical.RhythmStats.Percentile(90),
+			historical.RhythmStats.Percentile(99))))
+	}
 
 	return row.String()
 }
@@ -954,6 +1197,38 @@ func (r *Renderer) renderSFBStats(session *stats.Stats, historical *stats.Histor
 	return row.String()
 }
 
+// renderReactionStats renders the reaction time to a countdown start
+func (r *Renderer) renderReactionStats(session *stats.Stats, historical *stats.HistoricalStats, labelWidth int) string {
+	var row strings.Builder
+	labelStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color(ColourLabel)).
+		Width(labelWidth).
+		Align(lipgloss.Right)
+	valueStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(ColourValue))
+
+	row.WriteString(labelStyle.Render("Reaction:"))
+	row.WriteString(" ")
+
+	if session.ReactionTimeMs > 0 {
+		// Scale against a one second reaction, which is a slow start
+		maxReaction := 1000.0
+		performance := 100.0 - (float64(session.ReactionTimeMs)/maxReaction)*100
+		if performance < 0 {
+			performance = 0
+		}
+		style := lipgloss.NewStyle().Foreground(lipgloss.Color(GetAccuracyColour(performance)))
+		row.WriteString(style.Render(fmt.Sprintf("%dms", session.ReactionTimeMs)))
+	} else {
+		row.WriteString(valueStyle.Render("-"))
+	}
+	if historical.ReactionStats.Count > 0 {
+		row.WriteString(valueStyle.Render(fmt.Sprintf(" (best: %dms, avg: %.0fms)",
+			historical.ReactionStats.BestMs, historical.ReactionStats.AverageMs())))
+	}
+
+	return row.String()
+}
+
 // renderTopErrors renders top error substitution patterns
 func (r *Renderer) renderTopErrors(historical *stats.HistoricalStats, labelWidth int) string {
 	var row strings.Builder
@@ -1006,6 +1281,271 @@ func (r *Renderer) renderTopErrors(historical *stats.HistoricalStats, labelWidth
 	return row.String()
 }
 
+// RenderTrendsScreen renders rolling averages, improvement rate and letter trends
+func (r *Renderer) RenderTrendsScreen(trends stats.TrendReport, historical *stats.HistoricalStats, s *settings.Settings) string {
+	const labelWidth = 18
+	const valueWidth = 8
+	const barWidth = 30
+	const maxWPMDisplay = 120.0
+	const maxAccuracy = 100.0
+	const maxSparklineWidth = 60
+
+	title := r.styles.Title.Render("Progress Trends")
+
+	var trendLines []string
+	trendLines = append(trendLines, "")
+
+	if trends.Rounds == 0 {
+		trendLines = append(trendLines, r.styles.CountStyle.Render("Complete a few rounds to see your trends"))
+	} else {
+		trendLines = append(trendLines,
+			r.styles.SessionLabel.Render("Rounds analysed:")+" "+r.styles.SessionValue.Render(fmt.Sprintf("%d", trends.Rounds)))
+
+		// Rolling averages
+		trendLines = append(trendLines, "")
+		trendLines = append(trendLines, r.formatStatRow(
+			"WPM last 10:", fmt.Sprintf("%.1f", trends.RollingWPM10),
+			r.renderStatBar(trends.RollingWPM10, maxWPMDisplay, barWidth, false),
+			labelWidth, valueWidth))
+		trendLines = append(trendLines, r.formatStatRow(
+			"WPM last 50:", fmt.Sprintf("%.1f", trends.RollingWPM50),
+			r.renderStatBar(trends.RollingWPM50, maxWPMDisplay, barWidth, false),
+			labelWidth, valueWidth))
+		trendLines = append(trendLines, r.formatStatRow(
+			"Accuracy last 10:", fmt.Sprintf("%.1f%%", trends.RollingAccuracy10),
+			r.renderStatBar(trends.RollingAccuracy10, maxAccuracy, barWidth, false),
+			labelWidth, valueWidth))
+		trendLines = append(trendLines, r.formatStatRow(
+			"Accuracy last 50:", fmt.Sprintf("%.1f%%", trends.RollingAccuracy50),
+			r.renderStatBar(trends.RollingAccuracy50, maxAccuracy, barWidth, false),
+			labelWidth, valueWidth))
+
+		// Improvement rate and rolling WPM curve
+		trendLines = append(trendLines, "")
+		trendLines = append(trendLines, r.renderImprovementRow(trends, labelWidth))
+
+		series := trends.WPMSeries10
+		if len(series) > maxSparklineWidth {
+			series = series[len(series)-maxSparklineWidth:]
+		}
+		sparkline, minWPM, maxWPM := r.renderSparkline(series)
+		trendLines = append(trendLines, r.styles.Label.Render("WPM (10 avg):")+" "+sparkline+
+			r.styles.CountStyle.Render(fmt.Sprintf(" %.0f-%.0f", minWPM, maxWPM)))
+
+		// Per-letter accuracy trends
+		trendLines = append(trendLines, "")
+		trendLines = append(trendLines, r.styles.LetterLabel.Render("")+" "+r.renderLetterHeaderRow())
+		trendLines = append(trendLines, r.styles.LetterLabel.Render("Accuracy trend:")+" "+r.renderLetterTrendRow(trends))
+	}
+
+	// Practice calendar
+	trendLines = append(trendLines, "")
+	trendLines = append(trendLines, r.renderPracticeCalendar(historical, s, time.Now()))
+
+	// Main content (title + trends)
+	mainContent := lipgloss.JoinVertical(
+		lipgloss.Center,
+		title,
+		strings.Join(trendLines, "\n"),
+	)
+
+	// Fixed header at top
+	headerStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("14")).
+		Bold(true)
+	header := lipgloss.PlaceHorizontal(r.width, lipgloss.Center,
+		headerStyle.Render("🐒 BABOON - Typing Practice"))
+
+	// Fixed footer at bottom
+	footer := lipgloss.PlaceHorizontal(r.width, lipgloss.Center,
+		r.styles.Help.Render("Press T or ESC to go back | ENTER for a new round"))
+
+	// Calculate heights
+	headerHeight := 1
+	footerHeight := 1
+	contentHeight := strings.Count(mainContent, "\n") + 1
+	availableHeight := r.height - headerHeight - footerHeight - 2 // -2 for spacing
+
+	// Calculate top padding to center main content in available space
+	topPadding := (availableHeight - contentHeight) / 2
+	if topPadding < 0 {
+		topPadding = 0
+	}
+
+	// Build full screen layout
+	var fullContent strings.Builder
+
+	// Header at top (line 0)
+	fullContent.WriteString(header)
+	fullContent.WriteString("\n")
+
+	// Top padding to center content
+	for i := 0; i < topPadding; i++ {
+		fullContent.WriteString("\n")
+	}
+
+	// Main content (centered horizontally)
+	centeredMain := lipgloss.PlaceHorizontal(r.width, lipgloss.Center, mainContent)
+	fullContent.WriteString(centeredMain)
+
+	// Bottom padding to push footer to the bottom
+	currentHeight := headerHeight + 1 + topPadding + contentHeight
+	for i := currentHeight; i < r.height-footerHeight; i++ {
+		fullContent.WriteString("\n")
+	}
+
+	// Footer at bottom (last line)
+	fullContent.WriteString(footer)
+
+	return fullContent.String()
+}
+
+// renderDailyGoal renders today's progress towards the daily goal and the current streak
+func (r *Renderer) renderDailyGoal(historical *stats.HistoricalStats, s *settings.Settings, labelWidth, barWidth int) string {
+	var row strings.Builder
+	labelStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color(ColourSession)).
+		Width(labelWidth).
+		Align(lipgloss.Right)
+	valueStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(ColourValue))
+
+	goal := settings.DefaultSettings().DailyGoal
+	if s != nil {
+		goal = s.DailyGoal
+	}
+
+	today := historical.PracticeOn(time.Now())
+	progress := goal.Progress(today.Rounds, today.Minutes())
+	met := goal.Met(today.Rounds, today.Minutes())
+
+	row.WriteString(labelStyle.Render("Daily goal:"))
+	row.WriteString(" ")
+	row.WriteString(valueStyle.Render(fmt.Sprintf("%.0f/%d %s ", progress, goal.Target, goal.Unit)))
+	row.WriteString(r.renderStatBar(progress, float64(goal.Target), barWidth/2, met))
+
+	streak := historical.CurrentStreak(time.Now())
+	streakStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(ColourNewBest)).Bold(true)
+	row.WriteString(valueStyle.Render(" Streak: "))
+	row.WriteString(streakStyle.Render(fmt.Sprintf("%d day", streak)))
+	if streak != 1 {
+		row.WriteString(streakStyle.Render("s"))
+	}
+
+	return row.String()
+}
+
+// renderPracticeCalendar renders a GitHub-style heatmap of practice days for
+// the last few weeks, one row per weekday, coloured by progress towards the daily goal
+func (r *Renderer) renderPracticeCalendar(historical *stats.HistoricalStats, s *settings.Settings, now time.Time) string {
+	const weeks = 20
+
+	goal := settings.DefaultSettings().DailyGoal
+	if s != nil {
+		goal = s.DailyGoal
+	}
+
+	// Start on the Monday of the first week shown
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	offset := (int(today.Weekday()) + 6) % 7 // Days since Monday
+	start := today.AddDate(0, 0, -offset-(weeks-1)*7)
+
+	dayLabels := []string{"Mon", "", "Wed", "", "Fri", "", "Sun"}
+	labelStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(ColourHelp)).Width(4)
+
+	lines := make([]string, 0, 9)
+	lines = append(lines, r.styles.SessionLabel.Render("Practice calendar"))
+	for weekday := 0; weekday < 7; weekday++ {
+		var row strings.Builder
+		row.WriteString(labelStyle.Render(dayLabels[weekday]))
+		for week := 0; week < weeks; week++ {
+			day := start.AddDate(0, 0, week*7+weekday)
+			if day.After(today) {
+				row.WriteString("  ")
+				continue
+			}
+			practice := historical.PracticeOn(day)
+			colour := "237" // No practice
+			if practice.Rounds > 0 {
+				fraction := goal.Fraction(practice.Rounds, practice.Minutes())
+				switch {
+				case fraction >= 1:
+					colour = "46"
+				case fraction >= 0.5:
+					colour = "34"
+				default:
+					colour = "22"
+				}
+			}
+			style := lipgloss.NewStyle().Foreground(lipgloss.Color(colour))
+			row.WriteString(style.Render("■ "))
+		}
+		lines = append(lines, row.String())
+	}
+
+	summary := fmt.Sprintf("Current streak: %d days | Longest: %d days",
+		historical.CurrentStreak(now), historical.LongestStreak())
+	lines = append(lines, r.styles.CountStyle.Render(summary))
+
+	return lipgloss.JoinVertical(lipgloss.Left, lines...)
+}
+
+// renderImprovementRow renders the linear regression improvement rate
+func (r *Renderer) renderImprovementRow(trends stats.TrendReport, labelWidth int) string {
+	var row strings.Builder
+	labelStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color(ColourLabel)).
+		Width(labelWidth).
+		Align(lipgloss.Right)
+
+	row.WriteString(labelStyle.Render("Improvement:"))
+	row.WriteString(" ")
+
+	rate := trends.ImprovementWPMPerWeek
+	colour := "226" // Yellow for flat
+	if rate > 0.5 {
+		colour = "46"
+	} else if rate < -0.5 {
+		colour = "196"
+	}
+	style := lipgloss.NewStyle().Foreground(lipgloss.Color(colour)).Bold(true)
+	row.WriteString(style.Render(fmt.Sprintf("%+.1f WPM/week", rate)))
+
+	return row.String()
+}
+
+// renderLetterTrendRow renders a row of 26 arrows showing whether each
+// letter's accuracy is improving, steady or declining
+func (r *Renderer) renderLetterTrendRow(trends stats.TrendReport) string {
+	var row strings.Builder
+	letters := "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+
+	for i, letter := range letters {
+		lowerLetter := string(letter + 32)
+		trend, ok := trends.LetterTrends[lowerLetter]
+
+		symbol := "·"
+		colour := "240" // Gray for no data
+		if ok {
+			switch {
+			case trend.Change >= 1:
+				symbol, colour = "↑", "46"
+			case trend.Change <= -1:
+				symbol, colour = "↓", "196"
+			default:
+				symbol, colour = "→", "226"
+			}
+		}
+		style := lipgloss.NewStyle().Foreground(lipgloss.Color(colour))
+
+		row.WriteString(style.Render(symbol))
+		if i < len(letters)-1 {
+			row.WriteString(" ")
+		}
+	}
+
+	return row.String()
+}
+
 // RenderOptionsScreen renders the options/settings screen
 func (r *Renderer) RenderOptionsScreen(s *settings.Settings, cursor int) string {
 	title := r.styles.Title.Render("Options")
@@ -1021,58 +1561,34 @@ func (r *Renderer) RenderOptionsScreen(s *settings.Settings, cursor int) string
 		{settings.AdvanceKeyEither, "Either", "Press Space or Enter to advance to the next word"},
 	}
 
+	// Options for how rounds start, numbered after the advance keys
+	startOptions := []struct {
+		mode        settings.StartMode
+		label       string
+		description string
+	}{
+		{settings.StartModeFirstKey, "First key", "The clock starts on the first correct key (default)"},
+		{settings.StartModeCountdown, "Countdown", "A 3-2-1 countdown; the clock starts at go and reaction time is measured"},
+	}
+
 	var optionLines []string
 	optionLines = append(optionLines, "")
 	optionLines = append(optionLines, r.styles.SessionLabel.Render("Advance to next word with:"))
 	optionLines = append(optionLines, "")
 
 	for i, opt := range options {
-		// Build the option line
-		var line strings.Builder
-
-		// Number prefix
-		numStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
-		line.WriteString(numStyle.Render(fmt.Sprintf(" %d. ", i+1)))
-
-		// Selection indicator and label
-		isSelected := s.AdvanceKey == opt.key
-		isCursor := cursor == i
-
-		var labelStyle lipgloss.Style
-		if isCursor {
-			// Cursor position - highlighted
-			labelStyle = lipgloss.NewStyle().
-				Foreground(lipgloss.Color("0")).
-				Background(lipgloss.Color("39")).
-				Bold(true).
-				Padding(0, 1)
-		} else if isSelected {
-			// Currently selected option
-			labelStyle = lipgloss.NewStyle().
-				Foreground(lipgloss.Color("46")).
-				Bold(true)
-		} else {
-			// Normal option
-			labelStyle = lipgloss.NewStyle().
-				Foreground(lipgloss.Color("252"))
-		}
-
-		// Checkmark for selected option
-		if isSelected {
-			checkStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("46")).Bold(true)
-			line.WriteString(checkStyle.Render("✓ "))
-		} else {
-			line.WriteString("  ")
-		}
-
-		line.WriteString(labelStyle.Render(opt.label))
+		optionLines = append(optionLines, r.renderOptionLine(i, opt.label, opt.description,
+			s.AdvanceKey == opt.key, cursor == i))
+	}
 
-		// Description
-		descStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("240")).Italic(true)
-		line.WriteString("  ")
-		line.WriteString(descStyle.Render(opt.description))
+	optionLines = append(optionLines, "")
+	optionLines = append(optionLines, r.styles.SessionLabel.Render("Start rounds with:"))
+	optionLines = append(optionLines, "")
 
-		optionLines = append(optionLines, line.String())
+	for i, opt := range startOptions {
+		idx := len(options) + i
+		optionLines = append(optionLines, r.renderOptionLine(idx, opt.label, opt.description,
+			s.StartMode == opt.mode, cursor == idx))
 	}
 
 	// Main content (title + options)
@@ -1091,7 +1607,7 @@ func (r *Renderer) RenderOptionsScreen(s *settings.Settings, cursor int) string
 
 	// Fixed footer at bottom
 	footer := lipgloss.PlaceHorizontal(r.width, lipgloss.Center,
-		r.styles.Help.Render("↑/↓ to navigate | Enter/Space to select | 1-3 quick select | ESC to go back"))
+		r.styles.Help.Render("↑/↓ to navigate | Enter/Space to select | 1-5 quick select | ESC to go back"))
 
 	// Calculate heights
 	headerHeight := 1
@@ -1132,3 +1648,76 @@ func (r *Renderer) RenderOptionsScreen(s *settings.Settings, cursor int) string
 
 	return fullContent.String()
 }
+
+// renderOptionLine renders one numbered choice on the options screen
+func (r *Renderer) renderOptionLine(idx int, label, description string, isSelected, isCursor bool) string {
+	var line strings.Builder
+
+	// Number prefix
+	numStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
+	line.WriteString(numStyle.Render(fmt.Sprintf(" %d. ", idx+1)))
+
+	var labelStyle lipgloss.Style
+	if isCursor {
+		// Cursor position - highlighted
+		labelStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("0")).
+			Background(lipgloss.Color("39")).
+			Bold(true).
+			Padding(0, 1)
+	} else if isSelected {
+		// Currently selected option
+		labelStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("46")).
+			Bold(true)
+	} else {
+		// Normal option
+		labelStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("252"))
+	}
+
+	// Checkmark for selected option
+	if isSelected {
+		checkStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("46")).Bold(true)
+		line.WriteString(checkStyle.Render("✓ "))
+	} else {
+		line.WriteString("  ")
+	}
+
+	line.WriteString(labelStyle.Render(label))
+
+	// Description
+	descStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("240")).Italic(true)
+	line.WriteString("  ")
+	line.WriteString(descStyle.Render(description))
+
+	return line.String()
+}
+
+// RenderErrorFooter replaces the footer (last line) of a rendered screen with
+// an error from the server, so that failed requests are not silently ignored.
+func (r *Renderer) RenderErrorFooter(screen string, err error) string {
+	message := "Server error: " + err.Error()
+	var apiErr *backend.APIError
+	if errors.As(err, &apiErr) && apiErr.Code != "" {
+		message += " (" + apiErr.Code + ")"
+	}
+	if r.width > 0 && lipgloss.Width(message) > r.width {
+		message = truncateToWidth(message, r.width)
+	}
+	footer := lipgloss.PlaceHorizontal(r.width, lipgloss.Center, r.styles.ErrorStyle.Render(message))
+
+	if i := strings.LastIndex(screen, "\n"); i >= 0 {
+		return screen[:i+1] + footer
+	}
+	return footer
+}
+
+// truncateToWidth shortens s to at most width cells, ending in an ellipsis.
+func truncateToWidth(s string, width int) string {
+	runes := []rune(s)
+	for len(runes) > 0 && lipgloss.Width(string(runes))+1 > width {
+		runes = runes[:len(runes)-1]
+	}
+	return string(runes) + "…"
+}
@@ -0,0 +1,446 @@
+package frontend
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/timlinux/baboon/backend"
+	"github.com/timlinux/baboon/stats"
+	"github.com/timlinux/baboon/websocket"
+)
+
+// WSClient implements the backend.GameAPI interface over a WebSocket.
+// Sessions are created and deleted over REST; everything else is sent on
+// one persistent connection per session.
+//
+// Keystrokes and backspaces are sent without waiting for the reply and their
+// effect predicted locally, as the REST client does between batches. Spaces
+// and all other requests wait for the server's reply.
+type WSClient struct {
+	*Client // Session management over REST
+
+	conn *websocket.Conn
+
+	mu        sync.Mutex
+	nextID    int64
+	lastInput int64 // ID of the most recent input message sent
+	waiting   map[int64]chan backend.WSMessage
+	predicted *backend.GameState
+	readErr   error
+}
+
+// wsRequestTimeout bounds how long a request waits for its reply
+const wsRequestTimeout = 5 * time.Second
+
+// NewWSClient creates a new WebSocket API client.
+func NewWSClient(baseURL string, punctuationMode bool) *WSClient {
+	return &WSClient{Client: NewClient(baseURL, punctuationMode)}
+}
+
+// CreateSession creates a new session on the server and connects to it.
+func (c *WSClient) CreateSession() error {
+	if err := c.Client.CreateSession(); err != nil {
+		return err
+	}
+
+	url := "ws" + strings.TrimPrefix(c.sessionURL(), "http") + "/ws"
+	dialer := websocket.Dialer{
+		Timeout:     c.httpClient.Timeout,
+		Subprotocol: backend.WSSubprotocol,
+		TLSConfig:   c.tlsConfig,
+	}
+	if c.token != "" {
+		dialer.Header = http.Header{"Authorization": {"Bearer " + c.token}}
+	}
+	conn, err := dialer.Dial(url)
+	if err != nil {
+		c.Client.DeleteSession()
+		return fmt.Errorf("failed to connect websocket: %w", err)
+	}
+
+	c.mu.Lock()
+	c.conn = conn
+	c.waiting = make(map[int64]chan backend.WSMessage)
+	c.predicted = nil
+	c.readErr = nil
+	c.mu.Unlock()
+
+	go c.readLoop(conn)
+	return nil
+}
+
+// DeleteSession closes the connection and deletes the session from the server.
+func (c *WSClient) DeleteSession() error {
+	if c.conn != nil {
+		c.conn.Close()
+		c.conn = nil
+	}
+	return c.Client.DeleteSession()
+}
+
+// readLoop delivers replies until the connection closes.
+func (c *WSClient) readLoop(conn *websocket.Conn) {
+	for {
+		data, err := conn.ReadMessage()
+		if err != nil {
+			c.mu.Lock()
+			c.readErr = err
+			for id, ch := range c.waiting {
+				close(ch)
+				delete(c.waiting, id)
+			}
+			c.mu.Unlock()
+			return
+		}
+
+		var msg backend.WSMessage
+		if json.Unmarshal(data, &msg) != nil {
+			continue
+		}
+
+		c.mu.Lock()
+		var resp backend.InputResponse
+		if ch, ok := c.waiting[msg.ID]; ok {
+			delete(c.waiting, msg.ID)
+			ch <- msg
+		} else if msg.Type == backend.WSError {
+			// Any rejected input makes the prediction wrong, as it assumed
+			// the input was applied
+			c.setErr(replyError(msg), false)
+			c.predicted = nil
+		} else if msg.ID == c.lastInput && msg.Type == backend.WSInput && json.Unmarshal(msg.Data, &resp) == nil {
+			// Replies to earlier input are superseded by the prediction
+			// for later input; only the latest reply is authoritative
+			c.setErr(nil, false)
+			state := gameStateFromResponse(resp.State)
+			c.predicted = &state
+		}
+		c.mu.Unlock()
+	}
+}
+
+// newMessage builds a request with the next ID, registering reply to receive
+// the server's reply if it is not nil. It must be called with c.mu held.
+func (c *WSClient) newMessage(msgType string, data any, reply chan backend.WSMessage) (backend.WSMessage, error) {
+	msg := backend.WSMessage{Version: backend.WSProtocolVersion, Type: msgType}
+	if c.conn == nil {
+		return msg, errors.New("no session")
+	}
+	if c.readErr != nil {
+		return msg, c.readErr
+	}
+	if data != nil {
+		raw, err := json.Marshal(data)
+		if err != nil {
+			return msg, err
+		}
+		msg.Data = raw
+	}
+
+	c.nextID++
+	msg.ID = c.nextID
+	if reply != nil {
+		c.waiting[msg.ID] = reply
+	}
+	return msg, nil
+}
+
+// write sends a request built by newMessage.
+func (c *WSClient) write(msg backend.WSMessage) error {
+	out, _ := json.Marshal(msg)
+	if err := c.conn.WriteMessage(out); err != nil {
+		c.mu.Lock()
+		delete(c.waiting, msg.ID)
+		c.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// replyError returns the server's error from an error reply.
+func replyError(msg backend.WSMessage) error {
+	return &backend.APIError{Message: msg.Error, Code: msg.Code}
+}
+
+// request sends a request and decodes the reply data into out. The outcome
+// is recorded for Err.
+func (c *WSClient) request(msgType string, data, out any) (err error) {
+	read := msgType == backend.WSState || strings.HasPrefix(msgType, "stats.")
+	defer func() { c.setErr(err, read) }()
+
+	reply := make(chan backend.WSMessage, 1)
+	c.mu.Lock()
+	msg, err := c.newMessage(msgType, data, reply)
+	c.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	if err := c.write(msg); err != nil {
+		return err
+	}
+
+	select {
+	case msg, ok := <-reply:
+		if !ok {
+			return errors.New("connection closed")
+		}
+		if msg.Type == backend.WSError {
+			return replyError(msg)
+		}
+		if out == nil {
+			return nil
+		}
+		return json.Unmarshal(msg.Data, out)
+	case <-time.After(wsRequestTimeout):
+		c.mu.Lock()
+		delete(c.waiting, msg.ID)
+		c.mu.Unlock()
+		return fmt.Errorf("%s: no reply after %v", msgType, wsRequestTimeout)
+	}
+}
+
+// sendInput applies predict to the predicted state and sends the event
+// without waiting for the reply. The prediction and the message ID are
+// taken together, so a reply to earlier input never replaces it.
+func (c *WSClient) sendInput(event backend.InputEvent, predict func(state *backend.GameState)) {
+	c.ensureState()
+
+	c.mu.Lock()
+	if c.predicted == nil {
+		c.predicted = &backend.GameState{}
+	}
+	predict(c.predicted)
+	msg, err := c.newMessage(backend.WSInput, backend.InputRequest{Events: []backend.InputEvent{event}}, nil)
+	if err == nil {
+		c.lastInput = msg.ID
+	}
+	c.mu.Unlock()
+
+	if err == nil {
+		err = c.write(msg)
+	}
+	if err != nil {
+		c.setErr(err, false)
+	}
+}
+
+// ensureState fetches the game state if there is no prediction to build on.
+func (c *WSClient) ensureState() {
+	c.mu.Lock()
+	have := c.predicted != nil
+	c.mu.Unlock()
+	if have {
+		return
+	}
+
+	var resp backend.GameStateResponse
+	if err := c.request(backend.WSState, nil, &resp); err != nil {
+		return
+	}
+	c.mu.Lock()
+	if c.predicted == nil {
+		state := gameStateFromResponse(resp)
+		c.predicted = &state
+	}
+	c.mu.Unlock()
+}
+
+// StartRound starts a new round.
+func (c *WSClient) StartRound() {
+	c.request(backend.WSRound, nil, nil)
+
+	c.mu.Lock()
+	c.predicted = nil
+	c.mu.Unlock()
+	c.cachedSession = nil
+}
+
+// AbandonRound gives up the round in progress.
+func (c *WSClient) AbandonRound(keepPartial bool) bool {
+	var result backend.AbandonResponse
+	err := c.request(backend.WSAbandon, backend.AbandonRequest{KeepPartial: keepPartial}, &result)
+
+	c.mu.Lock()
+	c.predicted = nil
+	c.mu.Unlock()
+	c.cachedSession = nil
+	return err == nil && result.Abandoned
+}
+
+// StartTimer starts the round's timer on the server.
+func (c *WSClient) StartTimer() {
+	if c.conn == nil {
+		return
+	}
+	c.sendInput(backend.InputEvent{Type: backend.InputStart}, predictStart)
+}
+
+// PauseRound tells the server the round is paused.
+func (c *WSClient) PauseRound() {
+	if c.conn == nil {
+		return
+	}
+	c.sendInput(backend.InputEvent{Type: backend.InputPause}, func(*backend.GameState) {})
+}
+
+// ProcessKeystroke sends a keystroke (legacy, no timing).
+func (c *WSClient) ProcessKeystroke(char string) backend.KeystrokeResult {
+	return c.ProcessKeystrokeWithTiming(char, 0)
+}
+
+// ProcessKeystrokeWithTiming sends a keystroke with frontend-measured seek
+// time, returning the locally predicted result.
+func (c *WSClient) ProcessKeystrokeWithTiming(char string, seekTimeMs int64) backend.KeystrokeResult {
+	if c.conn == nil {
+		return backend.KeystrokeResult{}
+	}
+
+	var result backend.KeystrokeResult
+	event := backend.InputEvent{Type: backend.InputKeystroke, Char: char, SeekTimeMs: seekTimeMs}
+	c.sendInput(event, func(state *backend.GameState) {
+		result = predictKeystroke(state, char)
+	})
+	c.cachedSession = nil
+	return result
+}
+
+// ProcessBackspace sends a backspace, returning whether it is predicted to
+// remove a character.
+func (c *WSClient) ProcessBackspace() bool {
+	if c.conn == nil {
+		return false
+	}
+
+	var removed bool
+	c.sendInput(backend.InputEvent{Type: backend.InputBackspace}, func(state *backend.GameState) {
+		removed = predictBackspace(state)
+	})
+	return removed
+}
+
+// ProcessSpace sends a space (legacy, no timing).
+func (c *WSClient) ProcessSpace() backend.SpaceResult {
+	return c.ProcessSpaceWithTiming(0)
+}
+
+// ProcessSpaceWithTiming sends a space with frontend-measured seek time and
+// returns the server's result.
+func (c *WSClient) ProcessSpaceWithTiming(seekTimeMs int64) backend.SpaceResult {
+	if c.conn == nil {
+		return backend.SpaceResult{}
+	}
+
+	// The reply carries the state after the space, superseding any
+	// replies still outstanding for earlier input
+	c.mu.Lock()
+	c.lastInput = 0
+	c.mu.Unlock()
+
+	var resp backend.InputResponse
+	event := backend.InputEvent{Type: backend.InputSpace, SeekTimeMs: seekTimeMs}
+	err := c.request(backend.WSInput, backend.InputRequest{Events: []backend.InputEvent{event}}, &resp)
+
+	// Invalidate cache
+	c.cachedSession = nil
+	c.cachedHistorical = nil
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err != nil || len(resp.Results) == 0 {
+		c.predicted = nil
+		return backend.SpaceResult{}
+	}
+	state := gameStateFromResponse(resp.State)
+	c.predicted = &state
+
+	result := resp.Results[0]
+	return backend.SpaceResult{
+		Advanced:       result.Advanced,
+		RoundComplete:  result.RoundComplete,
+		TreatedAsError: result.TreatedAsError,
+	}
+}
+
+// SubmitTiming sends the final timing data when a round completes.
+func (c *WSClient) SubmitTiming(startTime, endTime time.Time, durationMs int64) {
+	c.request(backend.WSTiming, backend.TimingRequest{
+		StartTimeUnixMs: startTime.UnixMilli(),
+		EndTimeUnixMs:   endTime.UnixMilli(),
+		DurationMs:      durationMs,
+	}, nil)
+
+	// Invalidate cache
+	c.cachedSession = nil
+	c.cachedHistorical = nil
+}
+
+// GetGameState returns the current game state, predicted locally while
+// input replies are outstanding.
+func (c *WSClient) GetGameState() backend.GameState {
+	if c.conn == nil {
+		return backend.GameState{}
+	}
+
+	c.ensureState()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.predicted == nil {
+		return backend.GameState{}
+	}
+	return *c.predicted
+}
+
+// GetSessionStats fetches the session statistics.
+func (c *WSClient) GetSessionStats() *stats.Stats {
+	var sessionStats stats.Stats
+	if err := c.request(backend.WSSessionStats, nil, &sessionStats); err != nil {
+		if c.cachedSession != nil {
+			return c.cachedSession
+		}
+		return &stats.Stats{}
+	}
+
+	c.cachedSession = &sessionStats
+	return &sessionStats
+}
+
+// GetHistoricalStats fetches the historical statistics.
+func (c *WSClient) GetHistoricalStats() *stats.HistoricalStats {
+	var historicalStats stats.HistoricalStats
+	if err := c.request(backend.WSHistoricalStats, nil, &historicalStats); err != nil {
+		if c.cachedHistorical != nil {
+			return c.cachedHistorical
+		}
+		return &stats.HistoricalStats{}
+	}
+	initHistoricalMaps(&historicalStats)
+
+	c.cachedHistorical = &historicalStats
+	return &historicalStats
+}
+
+// GetTrends fetches trend analysis.
+func (c *WSClient) GetTrends() stats.TrendReport {
+	var trends stats.TrendReport
+	if err := c.request(backend.WSTrends, nil, &trends); err != nil {
+		return stats.TrendReport{}
+	}
+
+	if trends.LetterTrends == nil {
+		trends.LetterTrends = make(map[string]stats.LetterTrend)
+	}
+	return trends
+}
+
+// SaveStats saves the statistics via the server.
+func (c *WSClient) SaveStats() error {
+	return c.request(backend.WSSave, nil, nil)
+}
+
+// Ensure WSClient implements backend.GameAPI
+var _ backend.GameAPI = (*WSClient)(nil)
@@ -11,17 +11,42 @@
 //
 //	baboon              # Normal mode (starts backend + frontend)
 //	baboon -p           # Punctuation mode (words separated by punctuation)
+//	baboon -quotes      # Quote mode (type passages from the bundled quotes)
+//	baboon -quote-length short  # Quote mode with only short quotes
 //	baboon -port 8080   # Use custom port for REST API
 //	baboon -server      # Run backend server only (blocking)
+//	baboon -server -listen 0.0.0.0:8787  # Serve other machines on the network
 //	baboon -client      # Run frontend only (connect to existing backend)
+//	baboon -client -url http://host:8787 -token TOKEN  # Connect to a remote backend
+//	baboon -server -tls  # Serve HTTPS with a persisted self-signed certificate
+//	baboon -client -url https://host:8787 -fingerprint FP  # Trust only that certificate
+//	baboon -profile work  # Keep separate statistics for the "work" profile
+//	baboon -transport ws  # Talk to the backend over a WebSocket
+//	baboon -server -log-format json -log-level debug  # Structured logs on stderr
+//	baboon status       # Print today's practice progress (for shell prompts)
+//	baboon goal 5 rounds  # Set the daily practice goal
+//	baboon stats doctor   # Check statistics for inconsistencies
+//	baboon stats migrate -to kv  # Move statistics to the embedded database
+//	baboon stress         # Check concurrent sessions don't block each other
+//	baboon api spec       # Print the OpenAPI document for the REST API
+//	baboon token -profile work  # Print the API token for a profile
+//	baboon cert           # Print the self-signed certificate's fingerprint
+//	baboon hook test      # Send a sample round to the configured hooks
+//	baboon simulate -wpm 80  # Check the stats against a synthetic typist
 package main
 
 import (
+	"context"
+	"crypto/tls"
 	"flag"
 	"fmt"
+	"io"
+	"log/slog"
+	"net"
 	"os"
 	"os/signal"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"syscall"
 	"time"
@@ -29,83 +54,236 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/timlinux/baboon/backend"
 	"github.com/timlinux/baboon/frontend"
+	"github.com/timlinux/baboon/settings"
+	"github.com/timlinux/baboon/stats"
+	"github.com/timlinux/baboon/words"
 )
 
 func main() {
+	// Subcommands
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "status":
+			runStatus(os.Args[2:])
+			return
+		case "goal":
+			runGoal(os.Args[2:])
+			return
+		case "stats":
+			runStats(os.Args[2:])
+			return
+		case "stress":
+			runStress(os.Args[2:])
+			return
+		case "api":
+			runAPI(os.Args[2:])
+			return
+		case "token":
+			runToken(os.Args[2:])
+			return
+		case "cert":
+			runCert(os.Args[2:])
+			return
+		case "hook":
+			runHook(os.Args[2:])
+			return
+		case "simulate":
+			runSimulate(os.Args[2:])
+			return
+		}
+	}
+
 	// Parse command line flags
 	punctuationMode := flag.Bool("p", false, "Enable punctuation mode (words separated by punctuation + space)")
+	quotes := flag.Bool("quotes", false, "Type passages from the bundled quote corpus instead of common words")
+	quoteLength := flag.String("quote-length", words.QuoteLengthAny, "Play only quotes of this length: short, medium or long (implies -quotes)")
 	port := flag.Int("port", 8787, "Port for the REST API server")
 	serverOnly := flag.Bool("server", false, "Run backend server only (no TUI)")
 	clientOnly := flag.Bool("client", false, "Run frontend only (connect to existing backend)")
+	profile := flag.String("profile", stats.DefaultProfile, "Statistics profile to play as")
+	transport := flag.String("transport", frontend.TransportHTTP, "Client transport: http or ws (WebSocket)")
+	listen := flag.String("listen", "", "Address for the REST API server to listen on (default 127.0.0.1:<port>)")
+	serverURL := flag.String("url", "", "Backend URL for -client to connect to (default http://127.0.0.1:<port>)")
+	token := flag.String("token", os.Getenv("BABOON_TOKEN"), "API token for -client (default $BABOON_TOKEN, then the local token for -profile)")
+	selfSigned := flag.Bool("tls", false, "Serve HTTPS with a self-signed certificate kept in ~/.config/baboon/tls")
+	certFile := flag.String("tls-cert", "", "Serve HTTPS with this certificate (PEM, requires -tls-key)")
+	keyFile := flag.String("tls-key", "", "Private key for -tls-cert (PEM)")
+	fingerprint := flag.String("fingerprint", "", "SHA-256 fingerprint of the server certificate for -client to trust (see baboon cert)")
+	logLevel := flag.String("log-level", "info", "Server log level: debug, info, warn or error")
+	logFormat := flag.String("log-format", backend.LogFormatText, "Server log format: text or json")
+	logFile := flag.String("log-file", "", "Append server logs to this file (default stderr with -server, otherwise no logs)")
 	flag.Parse()
 
-	addr := fmt.Sprintf("127.0.0.1:%d", *port)
-	baseURL := fmt.Sprintf("http://%s", addr)
+	gameOpts := gameOptions{punctuation: *punctuationMode, quotes: *quotes || *quoteLength != "", quoteLength: *quoteLength}
+	tlsOpts := tlsOptions{selfSigned: *selfSigned, certFile: *certFile, keyFile: *keyFile}
+	logOpts := logOptions{level: *logLevel, format: *logFormat, file: *logFile}
+	addr := *listen
+	if addr == "" {
+		addr = fmt.Sprintf("127.0.0.1:%d", *port)
+	}
+	baseURL := *serverURL
+	if baseURL == "" {
+		scheme := "http://"
+		if tlsOpts.enabled() || *fingerprint != "" {
+			scheme = "https://"
+		}
+		baseURL = scheme + connectAddr(addr)
+	}
 
 	// Validate flags
 	if *serverOnly && *clientOnly {
 		fmt.Println("Error: cannot use both -server and -client flags")
 		os.Exit(1)
 	}
+	if !stats.ValidProfileName(*profile) {
+		fmt.Println("Error: profile names may only contain letters, digits, '-' and '_'")
+		os.Exit(1)
+	}
+	if *transport != frontend.TransportHTTP && *transport != frontend.TransportWebSocket {
+		fmt.Printf("Error: unknown transport %q (use %s or %s)\n", *transport, frontend.TransportHTTP, frontend.TransportWebSocket)
+		os.Exit(1)
+	}
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		fmt.Printf("Error: invalid -listen address %q: %v\n", addr, err)
+		os.Exit(1)
+	}
+	if !words.ValidQuoteLength(*quoteLength) {
+		fmt.Printf("Error: unknown quote length %q (use short, medium or long)\n", *quoteLength)
+		os.Exit(1)
+	}
+	if (*certFile == "") != (*keyFile == "") {
+		fmt.Println("Error: -tls-cert and -tls-key must be used together")
+		os.Exit(1)
+	}
+	if _, err := backend.NewLogger(io.Discard, logOpts.level, logOpts.format); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
 
 	// Server-only mode: run backend and block
 	if *serverOnly {
-		runServerOnly(addr, *punctuationMode)
+		runServerOnly(addr, tlsOpts, logOpts, gameOpts)
 		return
 	}
 
 	// Client-only mode: connect to existing backend
 	if *clientOnly {
-		runClientOnly(baseURL, *punctuationMode)
+		runClientOnly(baseURL, *transport, gameOpts, *profile, *token, *fingerprint)
 		return
 	}
 
 	// Default mode: start backend and frontend together
-	runCombined(addr, baseURL, *punctuationMode)
+	runCombined(addr, baseURL, tlsOpts, logOpts, *transport, gameOpts, *profile)
 }
 
 // runServerOnly starts the backend server and blocks until interrupted.
-func runServerOnly(addr string, punctuationMode bool) {
+func runServerOnly(addr string, tlsOpts tlsOptions, logOpts logOptions, gameOpts gameOptions) {
 	config := backend.DefaultConfig()
-	config.PunctuationMode = punctuationMode
+	gameOpts.apply(&config)
+	s := loadSettings()
+	config.Storage = s.Storage
+	config.Hooks = hookConfig(s)
 
 	server, err := backend.NewServer(config, addr)
 	if err != nil {
 		fmt.Printf("Error creating server: %v\n", err)
 		os.Exit(1)
 	}
+	tokens := loadTokens()
+	server.RequireTokens(tokens)
+	server.SetLogger(newLogger(logOpts, os.Stderr))
+	fingerprint := configureTLS(server, tlsOpts, addr)
 
 	// Write PID file for management scripts
 	pidFile := getPIDFilePath()
 	if err := os.WriteFile(pidFile, []byte(strconv.Itoa(os.Getpid())), 0644); err != nil {
 		fmt.Printf("Warning: could not write PID file: %v\n", err)
 	}
-	defer os.Remove(pidFile)
 
-	// Handle graceful shutdown
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
 
-	go func() {
-		<-sigChan
-		fmt.Println("\nShutting down server...")
-		os.Remove(pidFile)
-		os.Exit(0)
-	}()
-
 	fmt.Printf("Baboon backend server starting on %s\n", addr)
+	if fingerprint != "" {
+		fmt.Printf("Serving HTTPS; certificate fingerprint (SHA-256): %s\n", fingerprint)
+	}
+	if !isLoopback(addr) {
+		fmt.Println("Listening beyond this machine: clients need their profile's token (baboon token -profile NAME)")
+	}
 	fmt.Printf("PID: %d (written to %s)\n", os.Getpid(), pidFile)
 	fmt.Println("Press Ctrl+C to stop")
 
-	if err := server.Start(); err != nil {
+	serverErr := make(chan error, 1)
+	go func() {
+		serverErr <- server.Start()
+	}()
+
+	select {
+	case err := <-serverErr:
+		os.Remove(pidFile)
 		fmt.Printf("Server error: %v\n", err)
 		os.Exit(1)
+	case <-sigChan:
+	}
+
+	// Graceful shutdown: drain in-flight requests, then flush stats
+	fmt.Println("\nShutting down server...")
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	report, err := server.Shutdown(ctx)
+	os.Remove(pidFile)
+
+	printShutdownReport(report)
+	if err != nil {
+		fmt.Printf("Shutdown error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// shutdownTimeout bounds how long the server waits for in-flight requests.
+const shutdownTimeout = 10 * time.Second
+
+// printShutdownReport summarises the stats flushed during shutdown.
+func printShutdownReport(report backend.ShutdownReport) {
+	fmt.Printf("Closed %d active session(s)\n", report.Sessions)
+	if len(report.RoundsFlushed) == 0 {
+		fmt.Println("All stats were already saved")
+		return
+	}
+	profiles := make([]string, 0, len(report.RoundsFlushed))
+	for profile := range report.RoundsFlushed {
+		profiles = append(profiles, profile)
+	}
+	sort.Strings(profiles)
+	for _, profile := range profiles {
+		fmt.Printf("Saved %d unsaved round(s) for profile %s\n", report.RoundsFlushed[profile], profile)
 	}
 }
 
-// runClientOnly connects to an existing backend server.
-func runClientOnly(baseURL string, punctuationMode bool) {
-	client := frontend.NewClient(baseURL, punctuationMode)
+// runClientOnly connects to an existing backend server. Without a token the
+// profile's token is read from this machine's tokens file, which works when
+// the backend runs here too.
+func runClientOnly(baseURL, transport string, gameOpts gameOptions, profile, token, fingerprint string) {
+	client, err := frontend.NewSessionClient(transport, baseURL, gameOpts.punctuation)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	if fingerprint != "" {
+		if err := client.SetPinnedCertificate(fingerprint); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	if token == "" {
+		if token, err = loadTokens().Token(profile); err != nil {
+			fmt.Printf("Error reading API token: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	client.SetProfile(profile)
+	client.SetToken(token)
+	client.SetQuoteMode(gameOpts.quotes, gameOpts.quoteLength)
 
 	// Wait for server to be ready
 	fmt.Printf("Connecting to backend at %s...\n", baseURL)
@@ -132,20 +310,49 @@ func runClientOnly(baseURL string, punctuationMode bool) {
 }
 
 // runCombined starts both backend and frontend together (default mode).
-func runCombined(addr, baseURL string, punctuationMode bool) {
+func runCombined(addr, baseURL string, tlsOpts tlsOptions, logOpts logOptions, transport string, gameOpts gameOptions, profile string) {
 	config := backend.DefaultConfig()
-	config.PunctuationMode = punctuationMode
+	gameOpts.apply(&config)
+	s := loadSettings()
+	config.Storage = s.Storage
+	config.Hooks = hookConfig(s)
 
 	server, err := backend.NewServer(config, addr)
 	if err != nil {
 		fmt.Printf("Error creating server: %v\n", err)
 		os.Exit(1)
 	}
+	tokens := loadTokens()
+	server.RequireTokens(tokens)
+	token, err := tokens.Token(profile)
+	if err != nil {
+		fmt.Printf("Error issuing API token: %v\n", err)
+		os.Exit(1)
+	}
+	if logOpts.file != "" {
+		// The TUI owns the terminal, so logs only go to a file
+		server.SetLogger(newLogger(logOpts, nil))
+	}
+	fingerprint := configureTLS(server, tlsOpts, addr)
 
 	// Start server in background
 	server.StartAsync()
 
-	client := frontend.NewClient(baseURL, punctuationMode)
+	client, err := frontend.NewSessionClient(transport, baseURL, gameOpts.punctuation)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	client.SetProfile(profile)
+	client.SetToken(token)
+	client.SetQuoteMode(gameOpts.quotes, gameOpts.quoteLength)
+	if fingerprint != "" {
+		// The client trusts exactly the certificate its own server serves
+		if err := client.SetPinnedCertificate(fingerprint); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
 
 	// Wait for server to be ready
 	if err := client.WaitForServer(2 * time.Second); err != nil {
@@ -158,15 +365,182 @@ func runCombined(addr, baseURL string, punctuationMode bool) {
 		fmt.Printf("Error creating session: %v\n", err)
 		os.Exit(1)
 	}
-	defer client.DeleteSession()
 
 	// Create and run TUI
 	model := frontend.NewModel(client)
 	p := tea.NewProgram(model, tea.WithAltScreen())
-	if _, err := p.Run(); err != nil {
-		fmt.Printf("Error running program: %v\n", err)
+	_, runErr := p.Run()
+
+	// Stop the embedded server, flushing any stats not yet saved
+	client.DeleteSession()
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if _, err := server.Shutdown(ctx); err != nil {
+		fmt.Printf("Error saving stats: %v\n", err)
+	}
+
+	if runErr != nil {
+		fmt.Printf("Error running program: %v\n", runErr)
+		os.Exit(1)
+	}
+}
+
+// hookConfig returns the round hooks configured in settings. The webhook
+// secret may instead be given in $BABOON_WEBHOOK_SECRET, to keep it out of
+// the settings file.
+func hookConfig(s *settings.Settings) backend.HookConfig {
+	config := backend.HookConfig{
+		WebhookURL:    s.Hooks.WebhookURL,
+		WebhookSecret: s.Hooks.WebhookSecret,
+		Command:       s.Hooks.Command,
+	}
+	if secret := os.Getenv("BABOON_WEBHOOK_SECRET"); secret != "" {
+		config.WebhookSecret = secret
+	}
+	return config
+}
+
+// gameOptions selects what is typed in each round.
+type gameOptions struct {
+	punctuation bool
+	quotes      bool
+	quoteLength string // Quote length category; empty for any length
+}
+
+// apply sets the options as the server's defaults for new sessions.
+func (o gameOptions) apply(config *backend.Config) {
+	config.PunctuationMode = o.punctuation
+	config.QuoteMode = o.quotes
+	config.QuoteLength = o.quoteLength
+}
+
+// logOptions selects how the server logs.
+type logOptions struct {
+	level  string
+	format string
+	file   string // Append to this file rather than the default output
+}
+
+// newLogger creates the server's logger, writing to the log file if one was
+// given and to out otherwise.
+func newLogger(opts logOptions, out io.Writer) *slog.Logger {
+	if opts.file != "" {
+		f, err := os.OpenFile(opts.file, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			fmt.Printf("Error opening log file: %v\n", err)
+			os.Exit(1)
+		}
+		out = f
+	}
+	logger, err := backend.NewLogger(out, opts.level, opts.format)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	return logger
+}
+
+// tlsOptions selects how the server serves HTTPS.
+type tlsOptions struct {
+	selfSigned bool   // Use the persisted self-signed certificate
+	certFile   string // Or this certificate and key
+	keyFile    string
+}
+
+func (o tlsOptions) enabled() bool {
+	return o.selfSigned || o.certFile != ""
+}
+
+// configureTLS enables HTTPS on the server if requested, returning the
+// certificate's fingerprint, or "" when serving plain HTTP.
+func configureTLS(server *backend.Server, opts tlsOptions, addr string) string {
+	if !opts.enabled() {
+		return ""
+	}
+
+	certFile, keyFile := opts.certFile, opts.keyFile
+	var cert tls.Certificate
+	var err error
+	if certFile != "" {
+		cert, err = tls.LoadX509KeyPair(certFile, keyFile)
+	} else if certFile, keyFile, err = backend.GetTLSPaths(); err == nil {
+		cert, err = backend.LoadOrCreateCertificate(certFile, keyFile, certificateHosts(addr))
+	}
+	if err != nil {
+		fmt.Printf("Error loading TLS certificate: %v\n", err)
+		os.Exit(1)
+	}
+
+	server.EnableTLS(cert)
+	return backend.Fingerprint(cert)
+}
+
+// certificateHosts lists the names a self-signed certificate is issued for:
+// this machine's loopback and host names, and the listen address if specific.
+func certificateHosts(addr string) []string {
+	hosts := []string{"localhost", "127.0.0.1", "::1"}
+	if name, err := os.Hostname(); err == nil {
+		hosts = append(hosts, name)
+	}
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		if ip := net.ParseIP(host); host != "" && (ip == nil || !ip.IsUnspecified()) {
+			hosts = append(hosts, host)
+		}
+	}
+	return hosts
+}
+
+// loadSettings loads the settings, exiting if they cannot be read. Running
+// on with defaults would pick the wrong stats backend, and saving them would
+// overwrite a file that is damaged or from a newer version of Baboon.
+func loadSettings() *settings.Settings {
+	s, err := settings.Load()
+	if err != nil {
+		path, _ := settings.GetSettingsPath()
+		fmt.Printf("Error loading settings from %s: %v\n", path, err)
 		os.Exit(1)
 	}
+	return s
+}
+
+// loadTokens loads the API tokens, creating the tokens file on first run.
+func loadTokens() *backend.Tokens {
+	path, err := backend.GetTokensPath()
+	if err == nil {
+		var tokens *backend.Tokens
+		if tokens, err = backend.LoadTokens(path); err == nil {
+			return tokens
+		}
+	}
+	fmt.Printf("Error loading API tokens: %v\n", err)
+	os.Exit(1)
+	return nil
+}
+
+// connectAddr returns the address a local client uses to reach a server
+// listening on addr: a wildcard host is reached via the loopback address.
+func connectAddr(addr string) string {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+	if ip := net.ParseIP(host); host == "" || (ip != nil && ip.IsUnspecified()) {
+		host = "127.0.0.1"
+	}
+	return net.JoinHostPort(host, port)
+}
+
+// isLoopback reports whether a listen address only accepts local connections.
+func isLoopback(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
 }
 
 // getPIDFilePath returns the path to the PID file.
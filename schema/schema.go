@@ -0,0 +1,121 @@
+// Package schema provides versioning and step-wise migration of the JSON files
+// Baboon keeps in ~/.config/baboon.
+//
+// Each file stores its schema version in a top-level "version" field (files
+// written before versioning was introduced have none and are version 0).
+// Migrations operate on the decoded JSON document rather than on Go structs, so
+// an old migration keeps working however much the structs change later.
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// VersionKey is the top-level JSON field holding a file's schema version
+const VersionKey = "version"
+
+// ErrNewerVersion is returned when a file was written by a newer version of Baboon
+var ErrNewerVersion = errors.New("file was written by a newer version of baboon")
+
+// Migration upgrades a document from version From to version From+1
+type Migration struct {
+	From        int
+	Description string
+	Apply       func(doc map[string]any) error
+}
+
+// Version returns the schema version recorded in a JSON document (0 if absent)
+func Version(data []byte) (int, error) {
+	var header struct {
+		Version int `json:"version"`
+	}
+	if err := json.Unmarshal(data, &header); err != nil {
+		return 0, err
+	}
+	return header.Version, nil
+}
+
+// Migrate upgrades data to the current version by applying each migration in
+// turn. It returns the migrated document and the version the data started at.
+// Data already at the current version is returned unchanged.
+func Migrate(data []byte, current int, migrations []Migration) ([]byte, int, error) {
+	version, err := Version(data)
+	if err != nil {
+		return nil, 0, err
+	}
+	if version > current {
+		return nil, version, fmt.Errorf("%w (version %d, this build supports %d)", ErrNewerVersion, version, current)
+	}
+	if version == current {
+		return data, version, nil
+	}
+
+	// Decode numbers as json.Number so values round-trip exactly
+	var doc map[string]any
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, version, err
+	}
+
+	for v := version; v < current; v++ {
+		step, ok := findMigration(migrations, v)
+		if !ok {
+			return nil, version, fmt.Errorf("no migration from version %d", v)
+		}
+		if err := step.Apply(doc); err != nil {
+			return nil, version, fmt.Errorf("migration from version %d (%s) failed: %w", v, step.Description, err)
+		}
+		doc[VersionKey] = v + 1
+	}
+
+	migrated, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, version, err
+	}
+	return migrated, version, nil
+}
+
+// findMigration returns the migration that upgrades from the given version
+func findMigration(migrations []Migration, from int) (Migration, bool) {
+	for _, m := range migrations {
+		if m.From == from {
+			return m, true
+		}
+	}
+	return Migration{}, false
+}
+
+// BackupPath returns the path of the copy kept of a file before it is
+// migrated from the given version, e.g. stats.json.v0.bak
+func BackupPath(path string, version int) string {
+	return fmt.Sprintf("%s.v%d.bak", path, version)
+}
+
+// WriteBackup saves the pre-migration contents of a file. An existing backup
+// for the same version is left alone so the original file is never lost.
+func WriteBackup(path string, version int, data []byte) error {
+	backup := BackupPath(path, version)
+	if _, err := os.Stat(backup); err == nil {
+		return nil
+	}
+	return os.WriteFile(backup, data, 0644)
+}
+
+// Number returns a numeric field from a decoded document
+func Number(doc map[string]any, key string) (float64, bool) {
+	switch v := doc[key].(type) {
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	case float64:
+		return v, true
+	case int:
+		return float64(v), true
+	}
+	return 0, false
+}
@@ -38,8 +38,8 @@ else
     kill "$PID" 2>/dev/null || true
 fi
 
-# Wait for process to terminate
-for i in {1..10}; do
+# Wait for process to terminate (the server drains requests for up to 10 seconds)
+for i in {1..24}; do
     if ! kill -0 "$PID" 2>/dev/null; then
         echo "Backend stopped successfully"
         rm -f "$PID_FILE"
@@ -48,7 +48,7 @@ for i in {1..10}; do
     sleep 0.5
 done
 
-# If still running after 5 seconds
+# If still running after 12 seconds
 if kill -0 "$PID" 2>/dev/null; then
     echo "Backend did not stop gracefully, force killing..."
     kill -9 "$PID" 2>/dev/null || true
@@ -4,8 +4,27 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+
+	"github.com/timlinux/baboon/schema"
 )
 
+// SchemaVersion is the current version of the settings.json format.
+//
+//	0 - unversioned files
+//	1 - version field added
+const SchemaVersion = 1
+
+// settingsMigrations upgrade settings files one version at a time
+var settingsMigrations = []schema.Migration{
+	{
+		From: 0,
+		// Settings added after the file was written are filled in from
+		// DefaultSettings on load, so there is nothing to convert
+		Description: "add schema version",
+		Apply:       func(doc map[string]any) error { return nil },
+	},
+}
+
 // AdvanceKey defines the key(s) used to advance to the next word
 type AdvanceKey int
 
@@ -43,15 +62,109 @@ func (a AdvanceKey) KeyHint() string {
 	}
 }
 
+// GoalUnit defines what a daily practice goal is measured in
+type GoalUnit int
+
+const (
+	GoalUnitRounds  GoalUnit = iota // Default: rounds completed per day
+	GoalUnitMinutes                 // Minutes of typing per day
+)
+
+// String returns the display name for the goal unit
+func (g GoalUnit) String() string {
+	switch g {
+	case GoalUnitRounds:
+		return "rounds"
+	case GoalUnitMinutes:
+		return "minutes"
+	default:
+		return "rounds"
+	}
+}
+
+// StartMode defines what starts a round's clock
+type StartMode int
+
+const (
+	StartModeFirstKey  StartMode = iota // Default: the first correct key of the round
+	StartModeCountdown                  // A 3-2-1 countdown; the clock starts at "go"
+)
+
+// String returns the display name for the start mode
+func (m StartMode) String() string {
+	switch m {
+	case StartModeFirstKey:
+		return "first key"
+	case StartModeCountdown:
+		return "countdown"
+	default:
+		return "first key"
+	}
+}
+
+// DailyGoal is the amount of practice to aim for each day
+type DailyGoal struct {
+	Unit   GoalUnit `json:"unit"`
+	Target int      `json:"target"`
+}
+
+// Progress returns the amount done towards the goal, in the goal's unit
+func (g DailyGoal) Progress(rounds int, minutes float64) float64 {
+	if g.Unit == GoalUnitMinutes {
+		return minutes
+	}
+	return float64(rounds)
+}
+
+// Fraction returns progress towards the goal from 0 to 1
+func (g DailyGoal) Fraction(rounds int, minutes float64) float64 {
+	if g.Target <= 0 {
+		return 1
+	}
+	fraction := g.Progress(rounds, minutes) / float64(g.Target)
+	if fraction > 1 {
+		fraction = 1
+	}
+	return fraction
+}
+
+// Met returns true if the goal has been reached
+func (g DailyGoal) Met(rounds int, minutes float64) bool {
+	return g.Fraction(rounds, minutes) >= 1
+}
+
+// Hooks configures what the server notifies when a round completes
+type Hooks struct {
+	WebhookURL    string   `json:"webhook_url,omitempty"`    // Receives a JSON POST per round
+	WebhookSecret string   `json:"webhook_secret,omitempty"` // Signs webhook bodies (HMAC-SHA256)
+	Command       []string `json:"command,omitempty"`        // Program and arguments, given the round on stdin
+}
+
 // Settings holds user preferences
 type Settings struct {
+	Version    int        `json:"version"` // Schema version (see SchemaVersion)
 	AdvanceKey AdvanceKey `json:"advance_key"`
+	DailyGoal  DailyGoal  `json:"daily_goal"`
+	Storage    string     `json:"storage"` // Stats storage backend: "json" or "kv"
+	Hooks      Hooks      `json:"hooks"`
+
+	// KeepPartialRounds merges the letters typed in an abandoned round into
+	// the letter and finger stats; the round never counts towards WPM
+	KeepPartialRounds bool `json:"keep_partial_rounds"`
+
+	// StartMode selects what starts each round's clock
+	StartMode StartMode `json:"start_mode"`
 }
 
 // DefaultSettings returns the default settings
 func DefaultSettings() *Settings {
 	return &Settings{
+		Version:    SchemaVersion,
 		AdvanceKey: AdvanceKeySpace,
+		DailyGoal:  DailyGoal{Unit: GoalUnitRounds, Target: 5},
+		Storage:    "json",
+
+		KeepPartialRounds: true,
 	}
 }
 
@@ -83,12 +196,25 @@ func Load() (*Settings, error) {
 		return DefaultSettings(), err
 	}
 
-	var s Settings
-	if err := json.Unmarshal(data, &s); err != nil {
+	migrated, from, err := schema.Migrate(data, SchemaVersion, settingsMigrations)
+	if err != nil {
+		return DefaultSettings(), err
+	}
+	if from < SchemaVersion {
+		// Keep the original and persist the upgrade; failures only mean the
+		// migration runs again next time
+		if err := schema.WriteBackup(path, from, data); err == nil {
+			_ = os.WriteFile(path, migrated, 0644)
+		}
+	}
+
+	// Start from defaults so settings added in newer versions are populated
+	s := DefaultSettings()
+	if err := json.Unmarshal(migrated, s); err != nil {
 		return DefaultSettings(), err
 	}
 
-	return &s, nil
+	return s, nil
 }
 
 // Save saves settings to disk
@@ -98,6 +224,7 @@ func (s *Settings) Save() error {
 		return err
 	}
 
+	s.Version = SchemaVersion
 	data, err := json.MarshalIndent(s, "", "  ")
 	if err != nil {
 		return err
@@ -0,0 +1,209 @@
+package stats
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Issue describes an inconsistency found in historical stats
+type Issue struct {
+	Problem string // What is wrong
+	Repair  string // What Repair does about it (empty if it can only be reported)
+}
+
+// Diagnose reports inconsistencies in the historical stats without changing them
+func (h *HistoricalStats) Diagnose() []Issue {
+	return h.Clone().checkConsistency()
+}
+
+// Repair fixes the inconsistencies that can be repaired and returns every
+// issue found, including those that can only be reported
+func (h *HistoricalStats) Repair() []Issue {
+	return h.checkConsistency()
+}
+
+// checkConsistency runs every check in order, applying repairs as it goes so
+// later checks see the corrected data
+func (h *HistoricalStats) checkConsistency() []Issue {
+	var issues []Issue
+	report := func(repair, format string, args ...any) {
+		issues = append(issues, Issue{Problem: fmt.Sprintf(format, args...), Repair: repair})
+	}
+
+	// Round history ordering and length
+	if !sort.SliceIsSorted(h.Rounds, func(i, j int) bool {
+		return h.Rounds[i].Timestamp.Before(h.Rounds[j].Timestamp)
+	}) {
+		report("sort rounds by time", "round history is not in time order")
+		sort.SliceStable(h.Rounds, func(i, j int) bool {
+			return h.Rounds[i].Timestamp.Before(h.Rounds[j].Timestamp)
+		})
+	}
+	if len(h.Rounds) > MaxRoundHistory {
+		report("drop the oldest rounds", "round history has %d rounds (limit %d)", len(h.Rounds), MaxRoundHistory)
+		h.Rounds = h.Rounds[len(h.Rounds)-MaxRoundHistory:]
+	}
+
+	// Session count and totals. When every session is in the round history
+	// the totals can be recomputed exactly.
+	if h.TotalSessions < len(h.Rounds) {
+		report("use the round history count", "%d sessions recorded but %d rounds in history", h.TotalSessions, len(h.Rounds))
+		h.TotalSessions = len(h.Rounds)
+	}
+	if h.LegacySessions > h.TotalSessions {
+		report("cap legacy sessions at the session count", "%d legacy sessions but only %d sessions", h.LegacySessions, h.TotalSessions)
+		h.LegacySessions = h.TotalSessions
+	}
+	if h.TotalSessions > 0 && h.TotalSessions == len(h.Rounds) {
+		var wpm, accuracy, seconds float64
+		for _, round := range h.Rounds {
+			wpm += round.WPM
+			accuracy += round.Accuracy
+			seconds += round.DurationSeconds
+		}
+		if h.LegacySessions > 0 || !closeTo(h.TotalWPM, wpm) || !closeTo(h.TotalAccuracy, accuracy) || !closeTo(h.TotalTime, seconds) {
+			report("recompute totals from the round history", "running totals do not match the round history")
+			h.TotalWPM, h.TotalAccuracy, h.TotalTime = wpm, accuracy, seconds
+			h.LegacySessions = 0
+		}
+	} else if h.LegacySessions == 0 && h.TotalSessions > 0 && h.TotalWPM == 0 && h.BestWPM > 0 {
+		report("leave the sessions out of averages", "running totals are missing")
+		h.TotalWPM, h.TotalAccuracy, h.TotalTime = 0, 0, 0
+		h.LegacySessions = h.TotalSessions
+	}
+	if h.LegacySessions > 0 {
+		// Estimating from the bests would invent data, so it is only done
+		// when asked for with EstimateTotals
+		report("", "%d sessions recorded before running totals were saved are left out of averages (estimate them with -estimate-totals)", h.LegacySessions)
+	}
+
+	// Personal bests must cover every recorded round
+	var maxWPM, maxAccuracy, minTime float64
+	for _, round := range h.Rounds {
+		maxWPM = math.Max(maxWPM, round.WPM)
+		maxAccuracy = math.Max(maxAccuracy, round.Accuracy)
+		// Quote rounds have their own best times
+		if round.QuoteID == 0 && round.DurationSeconds > 0 && (minTime == 0 || round.DurationSeconds < minTime) {
+			minTime = round.DurationSeconds
+		}
+	}
+	if maxWPM > h.BestWPM {
+		report("raise best WPM", "best WPM %.1f is lower than a recorded round (%.1f)", h.BestWPM, maxWPM)
+		h.BestWPM = maxWPM
+	}
+	if maxAccuracy > h.BestAccuracy {
+		report("raise best accuracy", "best accuracy %.1f%% is lower than a recorded round (%.1f%%)", h.BestAccuracy, maxAccuracy)
+		h.BestAccuracy = maxAccuracy
+	}
+	if minTime > 0 && (h.BestTime == 0 || minTime < h.BestTime) {
+		report("lower best time", "best time %.1fs is slower than a recorded round (%.1fs)", h.BestTime, minTime)
+		h.BestTime = minTime
+	}
+
+	// Averages that cannot be right but whose cause is unknown
+	if h.TotalledSessions() > 0 {
+		avgWPM := h.AverageWPM()
+		if avgWPM > h.BestWPM+0.01 {
+			report("", "average WPM %.1f is higher than best WPM %.1f", avgWPM, h.BestWPM)
+		}
+		avgAccuracy := h.AverageAccuracy()
+		if avgAccuracy > 100.01 {
+			report("", "average accuracy %.1f%% is above 100%%", avgAccuracy)
+		}
+	}
+
+	// Per-letter counts
+	for _, letter := range sortedKeys(h.LetterAccuracy) {
+		letterStats := h.LetterAccuracy[letter]
+		if letterStats.Correct > letterStats.Presented {
+			report("cap correct at presented", "letter %q typed correctly %d times but presented only %d", letter, letterStats.Correct, letterStats.Presented)
+			letterStats.Correct = letterStats.Presented
+			h.LetterAccuracy[letter] = letterStats
+		}
+	}
+
+	// Seek time histogram
+	histogram := &h.RhythmStats.Histogram
+	if len(histogram.Counts) != 0 && len(histogram.Counts) != histNumBuckets {
+		report("reset the histogram", "seek time histogram has %d buckets (expected %d)", len(histogram.Counts), histNumBuckets)
+		*histogram = SeekHistogram{}
+	}
+	sum := 0
+	for _, count := range histogram.Counts {
+		sum += count
+	}
+	if histogram.Total != sum {
+		report("recount the histogram total", "seek time histogram total %d does not match its buckets (%d)", histogram.Total, sum)
+		histogram.Total = sum
+	}
+
+	return issues
+}
+
+// EstimateTotals adds estimates for the legacy sessions to the running
+// totals, taking each to have matched the personal bests, and returns the
+// number of sessions estimated. The averages this gives are upper bounds.
+func (h *HistoricalStats) EstimateTotals() int {
+	n := h.LegacySessions
+	if n <= 0 {
+		return 0
+	}
+	h.TotalWPM += h.BestWPM * float64(n)
+	h.TotalAccuracy += h.BestAccuracy * float64(n)
+	h.TotalTime += h.BestTime * float64(n)
+	h.LegacySessions = 0
+	return n
+}
+
+// closeTo compares running totals allowing for floating point drift
+func closeTo(a, b float64) bool {
+	return math.Abs(a-b) <= 0.01*math.Max(1, math.Abs(b))
+}
+
+// sortedKeys returns the keys of a letter map in order, for stable reports
+func sortedKeys(m map[string]LetterStats) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// DiagnoseStore loads stats from a store and reports any inconsistencies
+func DiagnoseStore(store StatsStore) ([]Issue, error) {
+	historical, err := store.Load()
+	if err != nil {
+		return nil, err
+	}
+	return historical.Diagnose(), nil
+}
+
+// RepairStore repairs the stats held in a store and returns the issues found.
+// The JSON store keeps the previous file as a rotating backup.
+func RepairStore(store StatsStore) ([]Issue, error) {
+	historical, err := store.Load()
+	if err != nil {
+		return nil, err
+	}
+	issues := historical.Repair()
+	if len(issues) == 0 {
+		return nil, nil
+	}
+	return issues, store.Replace(historical)
+}
+
+// EstimateStoreTotals estimates the running totals of legacy sessions in
+// a store from the personal bests and returns the number estimated.
+func EstimateStoreTotals(store StatsStore) (int, error) {
+	historical, err := store.Load()
+	if err != nil {
+		return 0, err
+	}
+	n := historical.EstimateTotals()
+	if n == 0 {
+		return 0, nil
+	}
+	return n, store.Replace(historical)
+}
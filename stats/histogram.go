@@ -0,0 +1,135 @@
+package stats
+
+import "sort"
+
+// Seek time histogram layout:
+// 10ms buckets from 0-1000ms (100 buckets), 50ms buckets from 1000-5000ms
+// (80 buckets) and a final overflow bucket for anything slower.
+const (
+	histFineWidthMs   = 10
+	histFineLimitMs   = 1000
+	histCoarseWidthMs = 50
+	histCoarseLimitMs = 5000
+	histFineBuckets   = histFineLimitMs / histFineWidthMs
+	histCoarseBuckets = (histCoarseLimitMs - histFineLimitMs) / histCoarseWidthMs
+	histNumBuckets    = histFineBuckets + histCoarseBuckets + 1
+)
+
+// SeekHistogram is a compact fixed-bucket histogram of seek times.
+// It allows percentiles to be estimated across thousands of rounds without
+// storing every individual measurement.
+type SeekHistogram struct {
+	Counts []int `json:"counts"` // Count per bucket (see bucket layout above)
+	Total  int   `json:"total"`  // Total number of measurements
+}
+
+// histBucketIndex returns the bucket index for a seek time in milliseconds
+func histBucketIndex(ms int64) int {
+	if ms < 0 {
+		ms = 0
+	}
+	if ms < histFineLimitMs {
+		return int(ms / histFineWidthMs)
+	}
+	if ms < histCoarseLimitMs {
+		return histFineBuckets + int((ms-histFineLimitMs)/histCoarseWidthMs)
+	}
+	return histNumBuckets - 1
+}
+
+// histBucketBounds returns the lower and upper bounds of a bucket in milliseconds
+func histBucketBounds(idx int) (float64, float64) {
+	if idx < histFineBuckets {
+		lower := float64(idx * histFineWidthMs)
+		return lower, lower + histFineWidthMs
+	}
+	if idx < histFineBuckets+histCoarseBuckets {
+		lower := float64(histFineLimitMs + (idx-histFineBuckets)*histCoarseWidthMs)
+		return lower, lower + histCoarseWidthMs
+	}
+	return histCoarseLimitMs, histCoarseLimitMs
+}
+
+// Add records a seek time in the histogram
+func (h *SeekHistogram) Add(ms int64) {
+	if len(h.Counts) != histNumBuckets {
+		h.Counts = make([]int, histNumBuckets)
+	}
+	h.Counts[histBucketIndex(ms)]++
+	h.Total++
+}
+
+// Merge adds all measurements from another histogram
+func (h *SeekHistogram) Merge(other SeekHistogram) {
+	if other.Total == 0 {
+		return
+	}
+	if len(h.Counts) != histNumBuckets {
+		h.Counts = make([]int, histNumBuckets)
+	}
+	for i, count := range other.Counts {
+		if i < histNumBuckets {
+			h.Counts[i] += count
+		}
+	}
+	h.Total += other.Total
+}
+
+// Percentile estimates the p-th percentile (0-100) in milliseconds,
+// interpolating linearly within the bucket that contains it
+func (h SeekHistogram) Percentile(p float64) float64 {
+	if h.Total == 0 || len(h.Counts) != histNumBuckets {
+		return 0
+	}
+	rank := p / 100 * float64(h.Total)
+	cumulative := 0
+	for i, count := range h.Counts {
+		if count == 0 {
+			continue
+		}
+		if float64(cumulative+count) >= rank {
+			lower, upper := histBucketBounds(i)
+			fraction := (rank - float64(cumulative)) / float64(count)
+			if fraction < 0 {
+				fraction = 0
+			}
+			return lower + fraction*(upper-lower)
+		}
+		cumulative += count
+	}
+	lower, _ := histBucketBounds(histNumBuckets - 1)
+	return lower
+}
+
+// percentileOf returns the exact p-th percentile (0-100) of a set of values
+// using linear interpolation between the closest ranks
+func percentileOf(values []int64, p float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := make([]int64, len(values))
+	copy(sorted, values)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	pos := p / 100 * float64(len(sorted)-1)
+	lower := int(pos)
+	if lower >= len(sorted)-1 {
+		return float64(sorted[len(sorted)-1])
+	}
+	fraction := pos - float64(lower)
+	return float64(sorted[lower]) + fraction*float64(sorted[lower+1]-sorted[lower])
+}
+
+// consistencyScore converts a mean and standard deviation into a 0-100
+// consistency percentage using the coefficient of variation (stddev / mean).
+// A perfectly even rhythm scores 100%.
+func consistencyScore(mean, stdDev float64) float64 {
+	if mean <= 0 {
+		return 0
+	}
+	score := 100 - (stdDev/mean)*100
+	if score < 0 {
+		return 0
+	}
+	return score
+}
@@ -0,0 +1,301 @@
+package stats
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// kvLog is a minimal embedded key-value database: an append-only log of
+// checksummed records with an in-memory index of the latest value of each key.
+//
+// Record layout (little endian):
+//
+//	crc32 (4) | op (1) | key length (4) | value length (4) | key | value
+//
+// The checksum covers everything after itself. Writes are grouped into
+// batches terminated by a commit record; on open, a torn or uncommitted
+// batch at the end of the log is discarded, so each batch is atomic. A
+// damaged record followed by later commits is not a crash but corruption,
+// and opening the log fails with ErrKVCorrupt rather than discard them.
+type kvLog struct {
+	file  *os.File
+	path  string
+	index map[string]kvEntry
+	size  int64 // Length of the valid log
+	live  int64 // Bytes used by records that are still current
+}
+
+// kvEntry locates the current value of a key in the log
+type kvEntry struct {
+	valueOffset int64
+	valueLen    int
+	recordLen   int64
+}
+
+// kvOp is a single operation within a batch
+type kvOp struct {
+	op    byte
+	key   string
+	value []byte
+}
+
+// Record operations
+const (
+	kvOpPut    byte = 1
+	kvOpDelete byte = 2
+	kvOpCommit byte = 3
+)
+
+const (
+	kvHeaderSize = 13
+	kvMaxField   = 64 << 20 // Guard against reading absurd lengths from a damaged log
+
+	// Compact once the log is at least this big and more than half garbage
+	kvCompactMinSize = 1 << 20
+)
+
+// ErrKVCorrupt is returned when a record in the middle of a key-value log is
+// damaged. The log is left as it is, as discarding the damaged record would
+// also discard every batch committed after it.
+var ErrKVCorrupt = errors.New("damaged record before the end of the log")
+
+// kvCommitRecord is the encoding of a commit record, which is always the same
+var kvCommitRecord = func() []byte {
+	var buf bytes.Buffer
+	encodeKVRecord(&buf, kvOpCommit, "", nil)
+	return buf.Bytes()
+}()
+
+// openKVLog opens or creates a log file and rebuilds its index
+func openKVLog(path string) (*kvLog, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, err
+	}
+	log := &kvLog{file: file, path: path, index: make(map[string]kvEntry)}
+	if err := log.replay(); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return log, nil
+}
+
+// replay scans the log, applying committed batches to the index. Anything
+// after the last complete commit is truncated, after copying the file to a
+// backup, unless a valid commit follows the damage.
+func (l *kvLog) replay() error {
+	reader := bufio.NewReader(l.file)
+	var offset int64
+	var pending []kvOp
+	var pendingEntries []kvEntry
+
+	for {
+		header := make([]byte, kvHeaderSize)
+		if _, err := io.ReadFull(reader, header); err != nil {
+			break // Clean end of log or torn header
+		}
+		op := header[4]
+		keyLen := binary.LittleEndian.Uint32(header[5:9])
+		valueLen := binary.LittleEndian.Uint32(header[9:13])
+		if keyLen > kvMaxField || valueLen > kvMaxField {
+			break
+		}
+		body := make([]byte, keyLen+valueLen)
+		if _, err := io.ReadFull(reader, body); err != nil {
+			break
+		}
+		checksum := crc32.NewIEEE()
+		checksum.Write(header[4:])
+		checksum.Write(body)
+		if checksum.Sum32() != binary.LittleEndian.Uint32(header[:4]) {
+			break
+		}
+
+		recordLen := int64(kvHeaderSize) + int64(len(body))
+		if op == kvOpCommit {
+			for i, p := range pending {
+				l.apply(p.op, p.key, pendingEntries[i])
+			}
+			pending, pendingEntries = nil, nil
+			offset += recordLen
+			l.size = offset
+			continue
+		}
+
+		pending = append(pending, kvOp{op: op, key: string(body[:keyLen])})
+		pendingEntries = append(pendingEntries, kvEntry{
+			valueOffset: offset + kvHeaderSize + int64(keyLen),
+			valueLen:    int(valueLen),
+			recordLen:   recordLen,
+		})
+		offset += recordLen
+	}
+
+	info, err := l.file.Stat()
+	if err != nil {
+		return err
+	}
+	if info.Size() == l.size {
+		return nil
+	}
+
+	// A crash can only tear the batch being written, so if a commit record
+	// follows the record that failed to read, the log is damaged instead
+	if offset < info.Size() {
+		rest, err := io.ReadAll(io.NewSectionReader(l.file, offset+1, info.Size()-offset-1))
+		if err != nil {
+			return err
+		}
+		if bytes.Contains(rest, kvCommitRecord) {
+			return fmt.Errorf("%w (offset %d)", ErrKVCorrupt, offset)
+		}
+	}
+
+	// Drop the torn or uncommitted tail so new batches follow the last commit
+	if err := rotateBackups(l.path); err != nil {
+		return fmt.Errorf("failed to back up before truncating: %w", err)
+	}
+	return l.file.Truncate(l.size)
+}
+
+// apply updates the index for one committed operation
+func (l *kvLog) apply(op byte, key string, entry kvEntry) {
+	if old, ok := l.index[key]; ok {
+		l.live -= old.recordLen
+	}
+	switch op {
+	case kvOpPut:
+		l.index[key] = entry
+		l.live += entry.recordLen
+	case kvOpDelete:
+		delete(l.index, key)
+	}
+}
+
+// Get returns the current value of a key
+func (l *kvLog) Get(key string) ([]byte, bool, error) {
+	entry, ok := l.index[key]
+	if !ok {
+		return nil, false, nil
+	}
+	value := make([]byte, entry.valueLen)
+	if _, err := l.file.ReadAt(value, entry.valueOffset); err != nil {
+		return nil, false, err
+	}
+	return value, true, nil
+}
+
+// Keys returns all keys with the given prefix in sorted order
+func (l *kvLog) Keys(prefix string) []string {
+	var keys []string
+	for key := range l.index {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Write appends a batch of operations followed by a commit record and syncs
+// the file. Either the whole batch survives a crash or none of it does.
+func (l *kvLog) Write(ops []kvOp) error {
+	var buf bytes.Buffer
+	entries := make([]kvEntry, len(ops))
+	for i, op := range ops {
+		offset := l.size + int64(buf.Len())
+		recordLen := encodeKVRecord(&buf, op.op, op.key, op.value)
+		entries[i] = kvEntry{
+			valueOffset: offset + kvHeaderSize + int64(len(op.key)),
+			valueLen:    len(op.value),
+			recordLen:   recordLen,
+		}
+	}
+	encodeKVRecord(&buf, kvOpCommit, "", nil)
+
+	if _, err := l.file.WriteAt(buf.Bytes(), l.size); err != nil {
+		return err
+	}
+	if err := l.file.Sync(); err != nil {
+		return err
+	}
+
+	for i, op := range ops {
+		l.apply(op.op, op.key, entries[i])
+	}
+	l.size += int64(buf.Len())
+	return nil
+}
+
+// encodeKVRecord appends a record to buf and returns its length
+func encodeKVRecord(buf *bytes.Buffer, op byte, key string, value []byte) int64 {
+	header := make([]byte, kvHeaderSize)
+	header[4] = op
+	binary.LittleEndian.PutUint32(header[5:9], uint32(len(key)))
+	binary.LittleEndian.PutUint32(header[9:13], uint32(len(value)))
+
+	checksum := crc32.NewIEEE()
+	checksum.Write(header[4:])
+	checksum.Write([]byte(key))
+	checksum.Write(value)
+	binary.LittleEndian.PutUint32(header[:4], checksum.Sum32())
+
+	buf.Write(header)
+	buf.WriteString(key)
+	buf.Write(value)
+	return int64(kvHeaderSize + len(key) + len(value))
+}
+
+// MaybeCompact rewrites the log with only current values once most of it is
+// superseded or deleted records
+func (l *kvLog) MaybeCompact() error {
+	if l.size < kvCompactMinSize || l.live*2 > l.size {
+		return nil
+	}
+
+	keys := l.Keys("")
+	ops := make([]kvOp, 0, len(keys))
+	for _, key := range keys {
+		value, _, err := l.Get(key)
+		if err != nil {
+			return err
+		}
+		ops = append(ops, kvOp{op: kvOpPut, key: key, value: value})
+	}
+
+	var buf bytes.Buffer
+	for _, op := range ops {
+		encodeKVRecord(&buf, op.op, op.key, op.value)
+	}
+	encodeKVRecord(&buf, kvOpCommit, "", nil)
+	if err := writeFileAtomic(l.path, buf.Bytes(), 0644); err != nil {
+		return err
+	}
+
+	// Reopen the compacted file
+	l.file.Close()
+	compacted, err := openKVLog(l.path)
+	if err != nil {
+		return err
+	}
+	*l = *compacted
+	return nil
+}
+
+// Close closes the log file
+func (l *kvLog) Close() error {
+	if l.file == nil {
+		return errors.New("kv log already closed")
+	}
+	err := l.file.Close()
+	l.file = nil
+	return err
+}
@@ -0,0 +1,224 @@
+package stats
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeKV opens the log at path, writes each batch and closes it again
+func writeKV(t *testing.T, path string, batches ...[]kvOp) {
+	t.Helper()
+	log, err := openKVLog(path)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer log.Close()
+	for _, batch := range batches {
+		if err := log.Write(batch); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+}
+
+func put(key, value string) kvOp {
+	return kvOp{op: kvOpPut, key: key, value: []byte(value)}
+}
+
+// wantKV checks the value of key in an open log; an empty want means absent
+func wantKV(t *testing.T, log *kvLog, key, want string) {
+	t.Helper()
+	value, ok, err := log.Get(key)
+	if err != nil {
+		t.Fatalf("get %s: %v", key, err)
+	}
+	if want == "" {
+		if ok {
+			t.Errorf("%s = %q, want absent", key, value)
+		}
+		return
+	}
+	if !ok || string(value) != want {
+		t.Errorf("%s = %q (present %v), want %q", key, value, ok, want)
+	}
+}
+
+func TestKVLogTornTail(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stats.db")
+	writeKV(t, path, []kvOp{put("a", "1")}, []kvOp{put("b", "2")})
+	committed, _ := os.ReadFile(path)
+
+	// A crash part way through the next batch leaves half a record
+	var torn bytes.Buffer
+	encodeKVRecord(&torn, kvOpPut, "c", []byte("3"))
+	damaged := append(append([]byte(nil), committed...), torn.Bytes()[:torn.Len()/2]...)
+	if err := os.WriteFile(path, damaged, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	log, err := openKVLog(path)
+	if err != nil {
+		t.Fatalf("open after torn write: %v", err)
+	}
+	wantKV(t, log, "a", "1")
+	wantKV(t, log, "b", "2")
+	wantKV(t, log, "c", "")
+	if err := log.Write([]kvOp{put("d", "4")}); err != nil {
+		t.Fatalf("write after recovery: %v", err)
+	}
+	log.Close()
+
+	if backup, err := os.ReadFile(path + ".1"); err != nil || !bytes.Equal(backup, damaged) {
+		t.Errorf("torn file was not backed up before truncating (err %v)", err)
+	}
+	log, err = openKVLog(path)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer log.Close()
+	wantKV(t, log, "b", "2")
+	wantKV(t, log, "d", "4")
+}
+
+func TestKVLogCorruptRecord(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stats.db")
+	writeKV(t, path, []kvOp{put("a", "first")}, []kvOp{put("b", "second")}, []kvOp{put("c", "third")})
+	data, _ := os.ReadFile(path)
+
+	// Damage the middle batch; the batch after it is still committed
+	i := bytes.Index(data, []byte("second"))
+	data[i] ^= 0xff
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := openKVLog(path); !errors.Is(err, ErrKVCorrupt) {
+		t.Fatalf("open damaged log: got %v, want ErrKVCorrupt", err)
+	}
+	if after, _ := os.ReadFile(path); !bytes.Equal(after, data) {
+		t.Error("damaged log was modified")
+	}
+	if _, err := DiagnoseStore(NewKVStore(path)); !errors.Is(err, ErrKVCorrupt) {
+		t.Errorf("doctor: got %v, want ErrKVCorrupt", err)
+	}
+}
+
+func TestKVLogUncommittedBatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stats.db")
+	writeKV(t, path, []kvOp{put("a", "1")})
+	committed, _ := os.ReadFile(path)
+
+	// Every record of the next batch made it to disk, but not its commit
+	var batch bytes.Buffer
+	encodeKVRecord(&batch, kvOpPut, "a", []byte("overwritten"))
+	encodeKVRecord(&batch, kvOpPut, "b", []byte("2"))
+	if err := os.WriteFile(path, append(append([]byte(nil), committed...), batch.Bytes()...), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	log, err := openKVLog(path)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer log.Close()
+	wantKV(t, log, "a", "1")
+	wantKV(t, log, "b", "")
+	if after, _ := os.ReadFile(path); !bytes.Equal(after, committed) {
+		t.Errorf("log is %d bytes after recovery, want the %d committed", len(after), len(committed))
+	}
+}
+
+func TestKVLogCompaction(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stats.db")
+	log, err := openKVLog(path)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer func() { log.Close() }()
+
+	// Overwrite one large value until the log is mostly superseded records
+	big := string(bytes.Repeat([]byte("x"), 64<<10))
+	if err := log.Write([]kvOp{put("keep", "kept"), put("gone", "deleted")}); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; log.size < 2*kvCompactMinSize; i++ {
+		if err := log.Write([]kvOp{put("big", big[:len(big)-i])}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := log.Write([]kvOp{{op: kvOpDelete, key: "gone"}}); err != nil {
+		t.Fatal(err)
+	}
+	want, _, _ := log.Get("big")
+	before := log.size
+
+	if err := log.MaybeCompact(); err != nil {
+		t.Fatalf("compact: %v", err)
+	}
+	if log.size >= before/4 {
+		t.Errorf("log is %d bytes after compaction, was %d", log.size, before)
+	}
+	if err := log.Write([]kvOp{put("after", "compaction")}); err != nil {
+		t.Fatalf("write after compaction: %v", err)
+	}
+	log.Close()
+
+	log, err = openKVLog(path)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	wantKV(t, log, "big", string(want))
+	wantKV(t, log, "keep", "kept")
+	wantKV(t, log, "gone", "")
+	wantKV(t, log, "after", "compaction")
+}
+
+func TestKVStoreReplaceKeepsOlderRounds(t *testing.T) {
+	store := NewKVStore(filepath.Join(t.TempDir(), "stats.db"))
+	historical := newHistoricalStats()
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < 5; i++ {
+		historical.UpdateHistorical(&Stats{
+			WPM:      float64(40 + i),
+			Accuracy: 95,
+			Duration: 30 * time.Second,
+			EndTime:  start.Add(time.Duration(i) * time.Hour),
+		})
+	}
+	if err := store.Save(historical); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+
+	// Replace only the two most recent rounds, as the doctor does after
+	// loading a limited history
+	replacement, err := store.Load()
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	replacement.Rounds = replacement.Rounds[3:]
+	replacement.Rounds[0].WPM = 100
+	if err := store.Replace(replacement); err != nil {
+		t.Fatalf("replace: %v", err)
+	}
+
+	loaded, err := store.Load()
+	if err != nil {
+		t.Fatalf("load after replace: %v", err)
+	}
+	var got []float64
+	for _, round := range loaded.Rounds {
+		got = append(got, round.WPM)
+	}
+	want := []float64{40, 41, 42, 100, 44}
+	if len(got) != len(want) {
+		t.Fatalf("rounds after replace = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("rounds after replace = %v, want %v", got, want)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package stats
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/timlinux/baboon/schema"
+)
+
+// SchemaVersion is the current version of the stats.json format.
+//
+//	0 - unversioned files, some written before averages were tracked
+//	1 - running totals (total_wpm, total_accuracy, total_time) always present
+//	2 - daily_practice log present alongside the round history
+const SchemaVersion = 2
+
+// statsMigrations upgrade stats files one version at a time
+var statsMigrations = []schema.Migration{
+	{
+		From:        0,
+		Description: "mark sessions recorded before averages were tracked as missing from the totals",
+		Apply:       migrateAddTotals,
+	},
+	{
+		From:        1,
+		Description: "build the daily practice log from the round history",
+		Apply:       migrateBackfillDailyPractice,
+	},
+}
+
+// migrateAddTotals adds the running totals that very early versions did not
+// save. Their sessions' values are lost, so the totals start at zero and the
+// sessions are counted as legacy sessions, leaving them out of averages; 'baboon
+// stats doctor -estimate-totals' can estimate them from the personal bests.
+func migrateAddTotals(doc map[string]any) error {
+	sessions, _ := schema.Number(doc, "total_sessions")
+	if sessions <= 0 {
+		return nil
+	}
+	_, hasWPM := doc["total_wpm"]
+	_, hasAccuracy := doc["total_accuracy"]
+	_, hasTime := doc["total_time"]
+	if hasWPM && hasAccuracy && hasTime {
+		return nil
+	}
+	doc["total_wpm"] = 0.0
+	doc["total_accuracy"] = 0.0
+	doc["total_time"] = 0.0
+	doc["legacy_sessions"] = sessions
+	return nil
+}
+
+// migrateBackfillDailyPractice builds the practice log used for streaks and
+// daily goals from rounds recorded before the log existed
+func migrateBackfillDailyPractice(doc map[string]any) error {
+	if practice, ok := doc["daily_practice"].(map[string]any); ok && len(practice) > 0 {
+		return nil
+	}
+	rounds, _ := doc["rounds"].([]any)
+
+	practice := make(map[string]DayPractice)
+	for _, r := range rounds {
+		round, ok := r.(map[string]any)
+		if !ok {
+			continue
+		}
+		stamp, _ := round["timestamp"].(string)
+		finished, err := time.Parse(time.RFC3339Nano, stamp)
+		if err != nil {
+			continue
+		}
+		seconds, _ := schema.Number(round, "duration_seconds")
+		day := practice[DayKey(finished)]
+		day.Rounds++
+		day.Seconds += seconds
+		practice[DayKey(finished)] = day
+	}
+	doc["daily_practice"] = practice
+	return nil
+}
+
+// migrateStatsFile upgrades a stats file on disk to the current schema,
+// keeping a copy of the original as a versioned backup
+func migrateStatsFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	version, err := schema.Version(data)
+	if err != nil || version == SchemaVersion {
+		// Unparseable files are reported by the caller when it decodes them
+		return nil
+	}
+
+	return withFileLock(path, func() error {
+		// Re-read under the lock in case another process migrated it first
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		migrated, from, err := schema.Migrate(data, SchemaVersion, statsMigrations)
+		if err != nil {
+			return err
+		}
+		if from == SchemaVersion {
+			return nil
+		}
+		if err := schema.WriteBackup(path, from, data); err != nil {
+			return fmt.Errorf("failed to back up stats before migration: %w", err)
+		}
+		return writeFileAtomic(path, migrated, 0644)
+	})
+}
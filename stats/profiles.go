@@ -0,0 +1,59 @@
+package stats
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+// DefaultProfile is the profile used when none is specified.
+// Its stats live in stats.json for compatibility with earlier versions.
+const DefaultProfile = "default"
+
+// ValidProfileName returns true if name is safe to use as a profile identifier
+// (1-32 characters of letters, digits, '-' or '_')
+func ValidProfileName(name string) bool {
+	if len(name) == 0 || len(name) > 32 {
+		return false
+	}
+	for _, c := range name {
+		isAlnum := (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+		if !isAlnum && c != '-' && c != '_' {
+			return false
+		}
+	}
+	return true
+}
+
+// GetProfileStatsPath returns the path to the stats file for a profile
+func GetProfileStatsPath(profile string) (string, error) {
+	path, err := GetStatsPath()
+	if err != nil {
+		return "", err
+	}
+	if profile == "" || profile == DefaultProfile {
+		return path, nil
+	}
+	if !ValidProfileName(profile) {
+		return "", fmt.Errorf("invalid profile name %q", profile)
+	}
+	return filepath.Join(filepath.Dir(path), "stats-"+profile+".json"), nil
+}
+
+// LoadProfileStats loads historical stats for a profile from disk
+func LoadProfileStats(profile string) (*HistoricalStats, error) {
+	path, err := GetProfileStatsPath(profile)
+	if err != nil {
+		return &HistoricalStats{}, err
+	}
+	return loadHistoricalStatsFrom(path)
+}
+
+// SaveProfileStats saves historical stats for a profile to disk.
+// See SaveHistoricalStats for how concurrent saves are merged.
+func SaveProfileStats(profile string, stats *HistoricalStats) error {
+	path, err := GetProfileStatsPath(profile)
+	if err != nil {
+		return err
+	}
+	return saveHistoricalStatsTo(path, stats)
+}
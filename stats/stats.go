@@ -2,9 +2,13 @@ package stats
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
 	"time"
+
+	"github.com/timlinux/baboon/schema"
 )
 
 // Stats represents typing statistics for a session
@@ -34,6 +38,36 @@ type Stats struct {
 	HandAlternations  int                        `json:"-"` // Count of hand alternations
 	SameHandRuns      int                        `json:"-"` // Count of same-hand consecutive pairs
 	SeekTimes         []int64                    `json:"-"` // All seek times for variance calculation
+
+	// Speed breakdown
+	RawWPM            float64   `json:"raw_wpm"`            // WPM counting every keystroke, including errors
+	NetWPM            float64   `json:"net_wpm"`            // Raw WPM penalised by uncorrected errors
+	CorrectedErrors   int       `json:"corrected_errors"`   // Errors fixed with backspace before advancing
+	UncorrectedErrors int       `json:"uncorrected_errors"` // Errors still present when the word was advanced
+	WordWPM           []float64 `json:"word_wpm"`           // Per-word WPM in the order the words were typed
+
+	// Correction behaviour
+	BackspaceCount int `json:"backspace_count"` // Number of backspaces that removed a character
+	CharsRetyped   int `json:"chars_retyped"`   // Keystrokes at positions already typed once in the word
+
+	// Rhythm summary (calculated when the round completes)
+	SeekTimeP50 float64 `json:"seek_time_p50"` // Median seek time in ms
+	SeekTimeP90 float64 `json:"seek_time_p90"` // 90th percentile seek time in ms
+	SeekTimeP99 float64 `json:"seek_time_p99"` // 99th percentile seek time in ms
+	Consistency float64 `json:"consistency"`   // Rhythm consistency percentage (100% = perfectly even)
+
+	// ReactionTimeMs is the time from "go" to the first key of a round
+	// started by a countdown; zero if the round started on its first key
+	ReactionTimeMs int64 `json:"reaction_time_ms"`
+
+	// Abandoned marks a round given up part way through. Only its keystroke
+	// data is merged into the historical stats; it never counts as a session.
+	Abandoned bool `json:"abandoned"`
+
+	// QuoteID and QuoteSource identify the passage typed in quote mode; the
+	// ID is zero for a round of common words
+	QuoteID     int    `json:"quote_id,omitempty"`
+	QuoteSource string `json:"quote_source,omitempty"`
 }
 
 // LetterStats tracks per-letter accuracy
@@ -156,12 +190,51 @@ func (s SFBStats) AverageMs() float64 {
 	return float64(s.TotalTimeMs) / float64(s.Count)
 }
 
+// QuoteBest tracks the best round typed for one quote
+type QuoteBest struct {
+	BestTime float64 `json:"best_time"` // Best (fastest) time in seconds
+	BestWPM  float64 `json:"best_wpm"`
+	Rounds   int     `json:"rounds"` // Completed rounds of this quote
+}
+
+// Record adds a completed round of the quote
+func (q *QuoteBest) Record(session *Stats) {
+	seconds := session.Duration.Seconds()
+	if q.Rounds == 0 || seconds < q.BestTime {
+		q.BestTime = seconds
+	}
+	if session.WPM > q.BestWPM {
+		q.BestWPM = session.WPM
+	}
+	q.Rounds++
+}
+
 // RhythmStats tracks typing rhythm consistency
 type RhythmStats struct {
 	TotalSeekTimeMs  int64   `json:"total_seek_time_ms"`
 	TotalSeekTimeSq  float64 `json:"total_seek_time_sq"` // Sum of squared seek times
 	Count            int     `json:"count"`
 	LastVariance     float64 `json:"last_variance"` // Last calculated variance
+
+	Histogram SeekHistogram `json:"histogram"` // Seek time distribution for percentiles
+}
+
+// Mean returns the mean seek time in milliseconds
+func (r RhythmStats) Mean() float64 {
+	if r.Count == 0 {
+		return 0
+	}
+	return float64(r.TotalSeekTimeMs) / float64(r.Count)
+}
+
+// Percentile returns the estimated p-th percentile seek time in milliseconds
+func (r RhythmStats) Percentile(p float64) float64 {
+	return r.Histogram.Percentile(p)
+}
+
+// Consistency returns the rhythm consistency percentage (100% = perfectly even)
+func (r RhythmStats) Consistency() float64 {
+	return consistencyScore(r.Mean(), r.StdDev())
 }
 
 // Variance returns the variance of seek times
@@ -194,8 +267,64 @@ func sqrt(x float64) float64 {
 	return z
 }
 
+// CorrectionStats tracks how errors are handled: fixed with backspace or left in place
+type CorrectionStats struct {
+	Backspaces        int `json:"backspaces"`         // Total backspaces that removed a character
+	CorrectedErrors   int `json:"corrected_errors"`   // Errors fixed before advancing
+	UncorrectedErrors int `json:"uncorrected_errors"` // Errors left in place when advancing
+	CharsRetyped      int `json:"chars_retyped"`      // Characters typed again after backspacing
+}
+
+// CorrectionRate returns the percentage of errors that were corrected
+func (c CorrectionStats) CorrectionRate() float64 {
+	total := c.CorrectedErrors + c.UncorrectedErrors
+	if total == 0 {
+		return 0
+	}
+	return float64(c.CorrectedErrors) / float64(total) * 100
+}
+
+// Efficiency returns corrected errors per backspace as a percentage.
+// 100% means every backspace removed a mistake; lower values mean correct
+// characters were deleted and retyped to reach an error.
+func (c CorrectionStats) Efficiency() float64 {
+	if c.Backspaces == 0 {
+		return 0
+	}
+	efficiency := float64(c.CorrectedErrors) / float64(c.Backspaces) * 100
+	if efficiency > 100 {
+		efficiency = 100
+	}
+	return efficiency
+}
+
+// ReactionStats tracks reaction times to the start of countdown rounds
+type ReactionStats struct {
+	TotalMs int64 `json:"total_ms"` // Total reaction time in milliseconds
+	Count   int   `json:"count"`    // Number of measurements
+	BestMs  int64 `json:"best_ms"`  // Fastest reaction
+}
+
+// AverageMs returns the average reaction time in milliseconds
+func (r ReactionStats) AverageMs() float64 {
+	if r.Count == 0 {
+		return 0
+	}
+	return float64(r.TotalMs) / float64(r.Count)
+}
+
+// Record adds a reaction time
+func (r *ReactionStats) Record(ms int64) {
+	if r.Count == 0 || ms < r.BestMs {
+		r.BestMs = ms
+	}
+	r.TotalMs += ms
+	r.Count++
+}
+
 // HistoricalStats stores best performance data
 type HistoricalStats struct {
+	Version         int                        `json:"version"` // Schema version (see SchemaVersion)
 	BestWPM         float64                    `json:"best_wpm"`
 	BestAccuracy    float64                    `json:"best_accuracy"`
 	BestTime        float64                    `json:"best_time"` // Best (fastest) time in seconds
@@ -203,6 +332,8 @@ type HistoricalStats struct {
 	TotalAccuracy   float64                    `json:"total_accuracy"`
 	TotalTime       float64                    `json:"total_time"` // Total time across all sessions
 	TotalSessions   int                        `json:"total_sessions"`
+	LegacySessions  int                        `json:"legacy_sessions,omitempty"` // Sessions missing from the totals, recorded before they were saved
+	AbandonedRounds int                        `json:"abandoned_rounds"`          // Rounds started but not finished
 	LastSessionDate time.Time                  `json:"last_session_date"`
 	LetterAccuracy  map[string]LetterStats     `json:"letter_accuracy"`  // Per-letter accuracy tracking
 	LetterSeekTime  map[string]LetterSeekStats `json:"letter_seek_time"` // Per-letter seek time tracking
@@ -217,6 +348,23 @@ type HistoricalStats struct {
 	HandAlternations  int                       `json:"hand_alternations"`  // Total hand alternations
 	SameHandRuns      int                       `json:"same_hand_runs"`     // Total same-hand consecutive pairs
 	RhythmStats       RhythmStats               `json:"rhythm_stats"`       // Rhythm consistency tracking
+	CorrectionStats   CorrectionStats           `json:"correction_stats"`   // Backspace and error correction tracking
+	ReactionStats     ReactionStats             `json:"reaction_stats"`     // Reaction to countdown starts
+	QuoteBests        map[int]QuoteBest         `json:"quote_bests"`        // Per-quote bests, keyed by quote ID
+
+	// Time series of recent rounds for trend analysis (capped at MaxRoundHistory)
+	Rounds []RoundSummary `json:"rounds"`
+
+	// Practice log keyed by local date (YYYY-MM-DD) for streaks and daily goals
+	DailyPractice map[string]DayPractice `json:"daily_practice"`
+
+	// pending holds sessions merged since this copy was loaded or last saved,
+	// so they can be replayed onto the file on disk without losing rounds
+	// recorded by other sessions in the meantime
+	pending []*Stats
+	// pendingAbandoned counts rounds abandoned since then without keeping
+	// their partial stats, which have no session to replay
+	pendingAbandoned int
 }
 
 // RecordLetterPresented records that a letter was presented to the user
@@ -388,6 +536,85 @@ func (s *Stats) CalculateRhythmStdDev() float64 {
 	return sqrt(variance)
 }
 
+// RecordWordTime records the speed of a completed word for the per-word speed curve
+func (s *Stats) RecordWordTime(chars int, durationMs int64) {
+	var wpm float64
+	if durationMs > 0 {
+		wpm = (float64(chars) / 5.0) / (float64(durationMs) / 60000.0)
+	}
+	s.WordWPM = append(s.WordWPM, wpm)
+}
+
+// RecordUncorrectedErrors records errors left in a word when advancing past it
+func (s *Stats) RecordUncorrectedErrors(count int) {
+	s.UncorrectedErrors += count
+}
+
+// RecordBackspace records a backspace that removed a character
+func (s *Stats) RecordBackspace() {
+	s.BackspaceCount++
+}
+
+// RecordRetype records a keystroke at a position that had already been typed
+func (s *Stats) RecordRetype() {
+	s.CharsRetyped++
+}
+
+// Corrections returns the session's correction behaviour as CorrectionStats
+func (s *Stats) Corrections() CorrectionStats {
+	return CorrectionStats{
+		Backspaces:        s.BackspaceCount,
+		CorrectedErrors:   s.CorrectedErrors,
+		UncorrectedErrors: s.UncorrectedErrors,
+		CharsRetyped:      s.CharsRetyped,
+	}
+}
+
+// CalculateSpeedBreakdown computes raw WPM, net WPM and corrected errors from the
+// raw counts and the session duration
+func (s *Stats) CalculateSpeedBreakdown() {
+	// Every incorrect keystroke was either fixed with backspace or left in place
+	s.CorrectedErrors = s.IncorrectChars - s.UncorrectedErrors
+	if s.CorrectedErrors < 0 {
+		s.CorrectedErrors = 0
+	}
+
+	minutes := s.Duration.Minutes()
+	if minutes <= 0 {
+		return
+	}
+
+	// Raw WPM: all keystrokes / 5 / minutes
+	// Net WPM: raw WPM minus uncorrected errors per minute
+	s.RawWPM = (float64(s.TotalCharacters) / 5.0) / minutes
+	s.NetWPM = s.RawWPM - float64(s.UncorrectedErrors)/minutes
+	if s.NetWPM < 0 {
+		s.NetWPM = 0
+	}
+}
+
+// SeekTimePercentile returns the exact p-th percentile of this session's seek times
+func (s *Stats) SeekTimePercentile(p float64) float64 {
+	return percentileOf(s.SeekTimes, p)
+}
+
+// CalculateRhythmSummary computes seek time percentiles and the consistency score
+func (s *Stats) CalculateRhythmSummary() {
+	s.SeekTimeP50 = s.SeekTimePercentile(50)
+	s.SeekTimeP90 = s.SeekTimePercentile(90)
+	s.SeekTimeP99 = s.SeekTimePercentile(99)
+
+	var mean float64
+	if len(s.SeekTimes) > 0 {
+		var sum int64
+		for _, t := range s.SeekTimes {
+			sum += t
+		}
+		mean = float64(sum) / float64(len(s.SeekTimes))
+	}
+	s.Consistency = consistencyScore(mean, s.CalculateRhythmStdDev())
+}
+
 // Calculate computes WPM and accuracy from raw stats
 func (s *Stats) Calculate() {
 	s.EndTime = time.Now()
@@ -404,6 +631,9 @@ func (s *Stats) Calculate() {
 	if s.TotalCharacters > 0 {
 		s.Accuracy = (float64(s.CorrectChars) / float64(s.TotalCharacters)) * 100
 	}
+
+	s.CalculateSpeedBreakdown()
+	s.CalculateRhythmSummary()
 }
 
 // GetStatsPath returns the path to the stats file
@@ -419,28 +649,55 @@ func GetStatsPath() (string, error) {
 	return filepath.Join(statsDir, "stats.json"), nil
 }
 
-// LoadHistoricalStats loads historical stats from disk
+// LoadHistoricalStats loads historical stats for the default profile from disk
 func LoadHistoricalStats() (*HistoricalStats, error) {
-	path, err := GetStatsPath()
-	if err != nil {
+	return LoadProfileStats(DefaultProfile)
+}
+
+// loadHistoricalStatsFrom loads historical stats from the given file,
+// first upgrading it to the current schema version if necessary
+func loadHistoricalStatsFrom(path string) (*HistoricalStats, error) {
+	if err := migrateStatsFile(path); err != nil {
 		return &HistoricalStats{}, err
 	}
+	return readHistoricalStats(path)
+}
 
+// readHistoricalStats reads historical stats from the given file without
+// modifying it; older schema versions are migrated in memory
+func readHistoricalStats(path string) (*HistoricalStats, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
 		if os.IsNotExist(err) {
-			return &HistoricalStats{
-				LetterAccuracy:    make(map[string]LetterStats),
-				LetterSeekTime:    make(map[string]LetterSeekStats),
-				BigramSeekTime:    make(map[string]BigramSeekStats),
-				FingerStats:       make(map[int]FingerStat),
-				HandStats:         make(map[int]HandStat),
-				RowStats:          make(map[int]RowStat),
-				ErrorSubstitution: make(map[string]map[string]int),
-			}, nil
+			return newHistoricalStats(), nil
 		}
 		return &HistoricalStats{}, err
 	}
+	return decodeHistoricalStats(data)
+}
+
+// newHistoricalStats returns empty historical stats at the current schema version
+func newHistoricalStats() *HistoricalStats {
+	return &HistoricalStats{
+		Version:           SchemaVersion,
+		LetterAccuracy:    make(map[string]LetterStats),
+		LetterSeekTime:    make(map[string]LetterSeekStats),
+		BigramSeekTime:    make(map[string]BigramSeekStats),
+		FingerStats:       make(map[int]FingerStat),
+		HandStats:         make(map[int]HandStat),
+		RowStats:          make(map[int]RowStat),
+		ErrorSubstitution: make(map[string]map[string]int),
+		DailyPractice:     make(map[string]DayPractice),
+	}
+}
+
+// decodeHistoricalStats parses serialised historical stats, migrating older
+// schema versions in memory
+func decodeHistoricalStats(data []byte) (*HistoricalStats, error) {
+	data, _, err := schema.Migrate(data, SchemaVersion, statsMigrations)
+	if err != nil {
+		return &HistoricalStats{}, err
+	}
 
 	var stats HistoricalStats
 	if err := json.Unmarshal(data, &stats); err != nil {
@@ -469,69 +726,164 @@ func LoadHistoricalStats() (*HistoricalStats, error) {
 	if stats.ErrorSubstitution == nil {
 		stats.ErrorSubstitution = make(map[string]map[string]int)
 	}
-
-	// Validate and fix corrupted averages from older versions
-	stats.validateAndFix()
+	if stats.DailyPractice == nil {
+		stats.DailyPractice = make(map[string]DayPractice)
+	}
 
 	return &stats, nil
 }
 
-// validateAndFix checks for inconsistent data and resets if needed
-func (h *HistoricalStats) validateAndFix() {
-	if h.TotalSessions == 0 {
-		return
-	}
+// SaveHistoricalStats saves historical stats to disk.
+// The file is locked for the duration of the save and re-read, then any
+// sessions recorded in this copy since it was loaded are merged into it, so
+// concurrent sessions never overwrite each other's rounds. The previous file
+// is kept as a rotating backup and the new one is written atomically.
+// On success, stats is updated to the merged result.
+func SaveHistoricalStats(stats *HistoricalStats) error {
+	return SaveProfileStats(DefaultProfile, stats)
+}
+
+// saveHistoricalStatsTo saves historical stats to the given file
+func saveHistoricalStatsTo(path string, stats *HistoricalStats) error {
+	return withFileLock(path, func() error {
+		merged, err := mergeWithDisk(path, stats)
+		if err != nil {
+			return err
+		}
+		merged.Version = SchemaVersion
+
+		data, err := json.MarshalIndent(merged, "", "  ")
+		if err != nil {
+			return err
+		}
 
-	// Check if averages are inconsistent (e.g., average time < best time is impossible
-	// if we have more than 1 session, or totals are 0 when they shouldn't be)
-	needsReset := false
+		if err := rotateBackups(path); err != nil {
+			return fmt.Errorf("failed to rotate backups: %w", err)
+		}
+		if err := writeFileAtomic(path, data, 0644); err != nil {
+			return err
+		}
 
-	// If we have sessions but no totals tracked, data is from old version
-	if h.TotalSessions > 0 && h.TotalWPM == 0 && h.BestWPM > 0 {
-		needsReset = true
+		*stats = *merged
+		stats.clearPending()
+		return nil
+	})
+}
+
+// mergeWithDisk replays the pending sessions of stats onto the current file
+// contents. If there is no readable file, stats already holds everything.
+// A file written by a newer version of Baboon is never overwritten.
+func mergeWithDisk(path string, stats *HistoricalStats) (*HistoricalStats, error) {
+	if _, err := os.Stat(path); err != nil {
+		return stats, nil
 	}
-	if h.TotalSessions > 0 && h.TotalTime == 0 && h.BestTime > 0 {
-		needsReset = true
+	disk, err := readHistoricalStats(path)
+	if errors.Is(err, schema.ErrNewerVersion) {
+		return nil, err
 	}
-	if h.TotalSessions > 0 && h.TotalAccuracy == 0 && h.BestAccuracy > 0 {
-		needsReset = true
+	if err != nil {
+		// Unreadable file (it is kept as a backup) - fall back to our copy
+		return stats, nil
 	}
 
-	// If average is less than best (impossible), data is corrupted
-	if h.TotalSessions > 1 {
-		avgWPM := h.TotalWPM / float64(h.TotalSessions)
-		if avgWPM > 0 && avgWPM < h.BestWPM*0.5 {
-			// Average WPM less than half of best is suspicious
-			needsReset = true
-		}
-	}
+	stats.replayPending(disk)
+	return disk, nil
+}
 
-	if needsReset {
-		// Reset totals based on best values as estimates
-		// This gives a reasonable starting point
-		h.TotalWPM = h.BestWPM * float64(h.TotalSessions)
-		h.TotalAccuracy = h.BestAccuracy * float64(h.TotalSessions)
-		h.TotalTime = h.BestTime * float64(h.TotalSessions)
+// replayPending records the pending sessions and abandonments of h onto
+// other, which is left with none pending.
+func (h *HistoricalStats) replayPending(other *HistoricalStats) {
+	for _, session := range h.pending {
+		other.UpdateHistorical(session)
 	}
+	for i := 0; i < h.pendingAbandoned; i++ {
+		other.RecordAbandoned()
+	}
+	other.clearPending()
 }
 
-// SaveHistoricalStats saves historical stats to disk
-func SaveHistoricalStats(stats *HistoricalStats) error {
-	path, err := GetStatsPath()
-	if err != nil {
-		return err
-	}
+// clearPending forgets the pending sessions once they have been saved
+func (h *HistoricalStats) clearPending() {
+	h.pending = nil
+	h.pendingAbandoned = 0
+}
 
-	data, err := json.MarshalIndent(stats, "", "  ")
-	if err != nil {
-		return err
+// PendingSessions returns the number of sessions recorded since the stats
+// were loaded or last saved
+func (h *HistoricalStats) PendingSessions() int {
+	return len(h.pending) + h.pendingAbandoned
+}
+
+// Clone returns a deep copy of the historical stats, including sessions
+// not yet saved, so the copy can be modified without affecting the original
+func (h *HistoricalStats) Clone() *HistoricalStats {
+	c := *h
+
+	c.LetterAccuracy = make(map[string]LetterStats, len(h.LetterAccuracy))
+	for k, v := range h.LetterAccuracy {
+		c.LetterAccuracy[k] = v
+	}
+	c.LetterSeekTime = make(map[string]LetterSeekStats, len(h.LetterSeekTime))
+	for k, v := range h.LetterSeekTime {
+		c.LetterSeekTime[k] = v
+	}
+	c.BigramSeekTime = make(map[string]BigramSeekStats, len(h.BigramSeekTime))
+	for k, v := range h.BigramSeekTime {
+		c.BigramSeekTime[k] = v
+	}
+	c.FingerStats = make(map[int]FingerStat, len(h.FingerStats))
+	for k, v := range h.FingerStats {
+		c.FingerStats[k] = v
+	}
+	c.HandStats = make(map[int]HandStat, len(h.HandStats))
+	for k, v := range h.HandStats {
+		c.HandStats[k] = v
+	}
+	c.RowStats = make(map[int]RowStat, len(h.RowStats))
+	for k, v := range h.RowStats {
+		c.RowStats[k] = v
+	}
+	c.ErrorSubstitution = make(map[string]map[string]int, len(h.ErrorSubstitution))
+	for expected, typedMap := range h.ErrorSubstitution {
+		c.ErrorSubstitution[expected] = make(map[string]int, len(typedMap))
+		for typed, count := range typedMap {
+			c.ErrorSubstitution[expected][typed] = count
+		}
+	}
+	c.QuoteBests = make(map[int]QuoteBest, len(h.QuoteBests))
+	for k, v := range h.QuoteBests {
+		c.QuoteBests[k] = v
+	}
+	c.DailyPractice = make(map[string]DayPractice, len(h.DailyPractice))
+	for k, v := range h.DailyPractice {
+		c.DailyPractice[k] = v
 	}
 
-	return os.WriteFile(path, data, 0644)
+	c.RhythmStats.Histogram.Counts = append([]int(nil), h.RhythmStats.Histogram.Counts...)
+	// Round summaries are never modified once recorded, so they can be shared
+	c.Rounds = append([]RoundSummary(nil), h.Rounds...)
+	c.pending = append([]*Stats(nil), h.pending...)
+
+	return &c
+}
+
+// RecordAbandoned counts a round abandoned without keeping its partial
+// stats.
+func (h *HistoricalStats) RecordAbandoned() {
+	h.AbandonedRounds++
+	h.pendingAbandoned++
 }
 
-// UpdateHistorical updates historical stats with new session data
+// UpdateHistorical updates historical stats with new session data. An
+// abandoned round is counted as such and its keystroke data merged, but it
+// does not affect sessions, totals, bests, trends or daily practice.
 func (h *HistoricalStats) UpdateHistorical(session *Stats) {
+	h.pending = append(h.pending, session)
+	if session.Abandoned {
+		h.AbandonedRounds++
+		h.mergeKeystrokes(session)
+		return
+	}
 	h.TotalSessions++
 	h.LastSessionDate = time.Now()
 
@@ -547,11 +899,37 @@ func (h *HistoricalStats) UpdateHistorical(session *Stats) {
 	if session.Accuracy > h.BestAccuracy {
 		h.BestAccuracy = session.Accuracy
 	}
-	// Best time is the fastest (lowest) time
-	if h.BestTime == 0 || session.Duration.Seconds() < h.BestTime {
+	// Best time is the fastest (lowest) time. A quote's length depends on
+	// the passage, so its time is only compared with that quote's rounds.
+	if session.QuoteID != 0 {
+		h.RecordQuote(session)
+	} else if h.BestTime == 0 || session.Duration.Seconds() < h.BestTime {
 		h.BestTime = session.Duration.Seconds()
 	}
 
+	h.mergeKeystrokes(session)
+
+	// Keep a summary of this round for trend analysis
+	summary := NewRoundSummary(session)
+	h.RecordRound(summary)
+
+	// Log the round against the day it was completed for streaks and goals
+	h.RecordPractice(summary.Timestamp, session.Duration.Seconds())
+}
+
+// RecordQuote records a completed quote round against its quote's bests
+func (h *HistoricalStats) RecordQuote(session *Stats) {
+	if h.QuoteBests == nil {
+		h.QuoteBests = make(map[int]QuoteBest)
+	}
+	best := h.QuoteBests[session.QuoteID]
+	best.Record(session)
+	h.QuoteBests[session.QuoteID] = best
+}
+
+// mergeKeystrokes merges a session's per-key accuracy, timing and
+// correction data into the historical stats
+func (h *HistoricalStats) mergeKeystrokes(session *Stats) {
 	// Merge session letter accuracy into historical
 	if h.LetterAccuracy == nil {
 		h.LetterAccuracy = make(map[string]LetterStats)
@@ -650,29 +1028,56 @@ func (h *HistoricalStats) UpdateHistorical(session *Stats) {
 		h.RhythmStats.TotalSeekTimeMs += seekTime
 		h.RhythmStats.TotalSeekTimeSq += float64(seekTime) * float64(seekTime)
 		h.RhythmStats.Count++
+		h.RhythmStats.Histogram.Add(seekTime)
 	}
+
+	// Merge correction stats
+	h.CorrectionStats.Backspaces += session.BackspaceCount
+	h.CorrectionStats.CorrectedErrors += session.CorrectedErrors
+	h.CorrectionStats.UncorrectedErrors += session.UncorrectedErrors
+	h.CorrectionStats.CharsRetyped += session.CharsRetyped
+
+	// Merge reaction time
+	if session.ReactionTimeMs > 0 {
+		h.ReactionStats.Record(session.ReactionTimeMs)
+	}
+}
+
+// TotalledSessions returns the number of sessions included in the running
+// totals
+func (h *HistoricalStats) TotalledSessions() int {
+	return max(h.TotalSessions-h.LegacySessions, 0)
 }
 
-// AverageWPM returns the average WPM across all sessions
+// AverageWPM returns the average WPM across all tracked sessions
 func (h *HistoricalStats) AverageWPM() float64 {
-	if h.TotalSessions == 0 {
+	if h.TotalledSessions() == 0 {
 		return 0
 	}
-	return h.TotalWPM / float64(h.TotalSessions)
+	return h.TotalWPM / float64(h.TotalledSessions())
 }
 
-// AverageAccuracy returns the average accuracy across all sessions
+// AverageAccuracy returns the average accuracy across all tracked sessions
 func (h *HistoricalStats) AverageAccuracy() float64 {
-	if h.TotalSessions == 0 {
+	if h.TotalledSessions() == 0 {
+		return 0
+	}
+	return h.TotalAccuracy / float64(h.TotalledSessions())
+}
+
+// AbandonRate returns the percentage of started rounds that were abandoned
+func (h *HistoricalStats) AbandonRate() float64 {
+	started := h.TotalSessions + h.AbandonedRounds
+	if started == 0 {
 		return 0
 	}
-	return h.TotalAccuracy / float64(h.TotalSessions)
+	return float64(h.AbandonedRounds) / float64(started) * 100
 }
 
-// AverageTime returns the average time across all sessions in seconds
+// AverageTime returns the average time across all tracked sessions in seconds
 func (h *HistoricalStats) AverageTime() float64 {
-	if h.TotalSessions == 0 {
+	if h.TotalledSessions() == 0 {
 		return 0
 	}
-	return h.TotalTime / float64(h.TotalSessions)
+	return h.TotalTime / float64(h.TotalledSessions())
 }
@@ -0,0 +1,280 @@
+package stats
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Storage backends for historical stats
+const (
+	StorageJSON = "json" // A single JSON file (stats.json)
+	StorageKV   = "kv"   // Embedded key-value log (stats.db) keeping every round
+)
+
+// StatsStore persists the historical stats of one profile
+type StatsStore interface {
+	// Load reads the stored stats
+	Load() (*HistoricalStats, error)
+
+	// Save merges the sessions recorded in stats since it was loaded into the
+	// store, so concurrent writers never lose each other's rounds. On success,
+	// stats is updated to the merged result.
+	Save(stats *HistoricalStats) error
+
+	// Replace overwrites the stored stats with stats, as used by repairs and
+	// migrations between backends. Stored rounds older than the oldest round
+	// in stats are kept by backends that hold more history than fits in memory.
+	Replace(stats *HistoricalStats) error
+
+	// Location describes where the data is stored, for messages
+	Location() string
+}
+
+// OpenStore returns the store for a profile using the named backend.
+// An empty backend selects StorageJSON.
+func OpenStore(backend, profile string) (StatsStore, error) {
+	path, err := GetProfileStatsPath(profile)
+	if err != nil {
+		return nil, err
+	}
+	switch backend {
+	case "", StorageJSON:
+		return NewJSONStore(path), nil
+	case StorageKV:
+		return NewKVStore(strings.TrimSuffix(path, filepath.Ext(path)) + ".db"), nil
+	}
+	return nil, fmt.Errorf("unknown storage backend %q (use %s or %s)", backend, StorageJSON, StorageKV)
+}
+
+// jsonStore keeps historical stats in a single JSON file
+type jsonStore struct {
+	path string
+}
+
+// NewJSONStore returns a store backed by the JSON file at path
+func NewJSONStore(path string) StatsStore {
+	return &jsonStore{path: path}
+}
+
+func (s *jsonStore) Load() (*HistoricalStats, error) {
+	return loadHistoricalStatsFrom(s.path)
+}
+
+func (s *jsonStore) Save(stats *HistoricalStats) error {
+	return saveHistoricalStatsTo(s.path, stats)
+}
+
+func (s *jsonStore) Replace(stats *HistoricalStats) error {
+	return withFileLock(s.path, func() error {
+		stats.Version = SchemaVersion
+		data, err := json.MarshalIndent(stats, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := rotateBackups(s.path); err != nil {
+			return fmt.Errorf("failed to rotate backups: %w", err)
+		}
+		if err := writeFileAtomic(s.path, data, 0644); err != nil {
+			return err
+		}
+		stats.clearPending()
+		return nil
+	})
+}
+
+func (s *jsonStore) Location() string {
+	return s.path
+}
+
+// Keys used in the key-value store. Rounds are stored individually, keyed by
+// time, so the full history is kept without rewriting it on every save.
+const (
+	kvStatsKey    = "stats"
+	kvRoundPrefix = "round/"
+)
+
+// kvStore keeps historical stats in an embedded key-value log. The
+// aggregates are stored under one key and every round under its own key;
+// Load returns the most recent MaxRoundHistory rounds, while older rounds
+// stay in the log. Rounds are stored as summaries: individual keystrokes
+// are not logged by either store.
+type kvStore struct {
+	path string
+}
+
+// NewKVStore returns a store backed by the key-value log at path
+func NewKVStore(path string) StatsStore {
+	return &kvStore{path: path}
+}
+
+func (s *kvStore) Load() (*HistoricalStats, error) {
+	var stats *HistoricalStats
+	err := s.withLog(func(log *kvLog) error {
+		var err error
+		stats, err = kvRead(log)
+		return err
+	})
+	if err != nil {
+		return &HistoricalStats{}, err
+	}
+	return stats, nil
+}
+
+func (s *kvStore) Save(stats *HistoricalStats) error {
+	return s.withLog(func(log *kvLog) error {
+		merged, err := kvRead(log)
+		if err != nil {
+			return err
+		}
+		// The new rounds are the last ones recorded by the replay below;
+		// abandoned rounds add none
+		added := 0
+		for _, session := range stats.pending {
+			if !session.Abandoned {
+				added++
+			}
+		}
+		stats.replayPending(merged)
+
+		if added > len(merged.Rounds) {
+			added = len(merged.Rounds)
+		}
+		first := len(log.Keys(kvRoundPrefix))
+		ops, err := kvRoundOps(merged.Rounds[len(merged.Rounds)-added:], first)
+		if err != nil {
+			return err
+		}
+		statsOp, err := kvStatsOp(merged)
+		if err != nil {
+			return err
+		}
+		if err := log.Write(append(ops, statsOp)); err != nil {
+			return err
+		}
+
+		*stats = *merged
+		return log.MaybeCompact()
+	})
+}
+
+func (s *kvStore) Replace(stats *HistoricalStats) error {
+	return s.withLog(func(log *kvLog) error {
+		var ops []kvOp
+
+		// Replace the rounds covered by stats, keeping anything older
+		existing := log.Keys(kvRoundPrefix)
+		if len(stats.Rounds) > 0 {
+			oldest := kvRoundTimePrefix(stats.Rounds[0].Timestamp)
+			for _, key := range existing {
+				if key >= oldest {
+					ops = append(ops, kvOp{op: kvOpDelete, key: key})
+				}
+			}
+		}
+		roundOps, err := kvRoundOps(stats.Rounds, len(existing))
+		if err != nil {
+			return err
+		}
+		ops = append(ops, roundOps...)
+
+		statsOp, err := kvStatsOp(stats)
+		if err != nil {
+			return err
+		}
+		if err := log.Write(append(ops, statsOp)); err != nil {
+			return err
+		}
+		stats.clearPending()
+		return log.MaybeCompact()
+	})
+}
+
+func (s *kvStore) Location() string {
+	return s.path
+}
+
+// withLog opens the log under the store's file lock for the duration of fn
+func (s *kvStore) withLog(fn func(log *kvLog) error) error {
+	return withFileLock(s.path, func() error {
+		log, err := openKVLog(s.path)
+		if err != nil {
+			return err
+		}
+		defer log.Close()
+		return fn(log)
+	})
+}
+
+// kvRead assembles historical stats from the aggregates and recent rounds
+func kvRead(log *kvLog) (*HistoricalStats, error) {
+	stats := newHistoricalStats()
+	data, ok, err := log.Get(kvStatsKey)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		if stats, err = decodeHistoricalStats(data); err != nil {
+			return nil, err
+		}
+	}
+
+	keys := log.Keys(kvRoundPrefix)
+	if len(keys) > MaxRoundHistory {
+		keys = keys[len(keys)-MaxRoundHistory:]
+	}
+	stats.Rounds = make([]RoundSummary, 0, len(keys))
+	for _, key := range keys {
+		data, _, err := log.Get(key)
+		if err != nil {
+			return nil, err
+		}
+		var round RoundSummary
+		if err := json.Unmarshal(data, &round); err != nil {
+			return nil, fmt.Errorf("round %s: %w", key, err)
+		}
+		stats.Rounds = append(stats.Rounds, round)
+	}
+	return stats, nil
+}
+
+// kvStatsOp serialises the aggregates, without the rounds which are stored separately
+func kvStatsOp(stats *HistoricalStats) (kvOp, error) {
+	aggregates := *stats
+	aggregates.Version = SchemaVersion
+	aggregates.Rounds = nil
+	data, err := json.Marshal(&aggregates)
+	if err != nil {
+		return kvOp{}, err
+	}
+	return kvOp{op: kvOpPut, key: kvStatsKey, value: data}, nil
+}
+
+// kvRoundOps serialises rounds, numbering them from seq to keep keys unique
+func kvRoundOps(rounds []RoundSummary, seq int) ([]kvOp, error) {
+	ops := make([]kvOp, 0, len(rounds))
+	for i, round := range rounds {
+		data, err := json.Marshal(round)
+		if err != nil {
+			return nil, err
+		}
+		ops = append(ops, kvOp{op: kvOpPut, key: kvRoundKey(round.Timestamp, seq+i), value: data})
+	}
+	return ops, nil
+}
+
+// kvRoundKey orders rounds by time: round/<unix nanoseconds>/<sequence>
+func kvRoundKey(t time.Time, seq int) string {
+	return fmt.Sprintf("%s/%08d", kvRoundTimePrefix(t), seq)
+}
+
+// kvRoundTimePrefix returns the part of a round key that encodes its time
+func kvRoundTimePrefix(t time.Time) string {
+	nanos := int64(0)
+	if t.Unix() > 0 {
+		nanos = t.UnixNano()
+	}
+	return fmt.Sprintf("%s%020d", kvRoundPrefix, nanos)
+}
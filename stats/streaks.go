@@ -0,0 +1,89 @@
+package stats
+
+import "time"
+
+// dayKeyFormat is the layout used for DailyPractice keys (local date)
+const dayKeyFormat = "2006-01-02"
+
+// DayPractice records how much practice was done on a single day
+type DayPractice struct {
+	Rounds  int     `json:"rounds"`  // Rounds completed on this day
+	Seconds float64 `json:"seconds"` // Total typing time in seconds
+}
+
+// Minutes returns the practice time in minutes
+func (d DayPractice) Minutes() float64 {
+	return d.Seconds / 60
+}
+
+// DayKey returns the DailyPractice key for the local date of t
+func DayKey(t time.Time) string {
+	return t.Local().Format(dayKeyFormat)
+}
+
+// RecordPractice adds a completed round to the practice log for the day it finished
+func (h *HistoricalStats) RecordPractice(finished time.Time, seconds float64) {
+	if h.DailyPractice == nil {
+		h.DailyPractice = make(map[string]DayPractice)
+	}
+	key := DayKey(finished)
+	day := h.DailyPractice[key]
+	day.Rounds++
+	day.Seconds += seconds
+	h.DailyPractice[key] = day
+}
+
+// PracticeOn returns the practice recorded on the local date of t
+func (h *HistoricalStats) PracticeOn(t time.Time) DayPractice {
+	return h.DailyPractice[DayKey(t)]
+}
+
+// CurrentStreak returns the number of consecutive days with practice ending
+// today. If there has been no practice yet today, the streak ending yesterday
+// is still considered current so it isn't lost before the day is over.
+func (h *HistoricalStats) CurrentStreak(now time.Time) int {
+	day := startOfDay(now)
+	if h.PracticeOn(day).Rounds == 0 {
+		day = day.AddDate(0, 0, -1)
+	}
+
+	streak := 0
+	for h.PracticeOn(day).Rounds > 0 {
+		streak++
+		day = day.AddDate(0, 0, -1)
+	}
+	return streak
+}
+
+// LongestStreak returns the longest run of consecutive practice days on record
+func (h *HistoricalStats) LongestStreak() int {
+	longest := 0
+	for key, practice := range h.DailyPractice {
+		if practice.Rounds == 0 {
+			continue
+		}
+		day, err := time.ParseInLocation(dayKeyFormat, key, time.Local)
+		if err != nil {
+			continue
+		}
+		// Only count from the first day of each run
+		if h.PracticeOn(day.AddDate(0, 0, -1)).Rounds > 0 {
+			continue
+		}
+		length := 0
+		for h.PracticeOn(day).Rounds > 0 {
+			length++
+			day = day.AddDate(0, 0, 1)
+		}
+		if length > longest {
+			longest = length
+		}
+	}
+	return longest
+}
+
+// startOfDay returns midnight local time on the date of t
+func startOfDay(t time.Time) time.Time {
+	year, month, day := t.Local().Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.Local)
+}
@@ -0,0 +1,220 @@
+package stats
+
+import "time"
+
+// MaxRoundHistory is the maximum number of round summaries kept in HistoricalStats.
+// Older rounds are dropped once the limit is reached; their totals remain in the
+// all-time aggregates.
+const MaxRoundHistory = 1000
+
+// Rolling window sizes used for trend analysis
+const (
+	ShortTrendWindow = 10
+	LongTrendWindow  = 50
+)
+
+// RoundSummary is a compact record of a single completed round, kept as a time
+// series so progress over time can be analysed
+type RoundSummary struct {
+	Timestamp       time.Time              `json:"timestamp"`
+	WPM             float64                `json:"wpm"`
+	NetWPM          float64                `json:"net_wpm"`
+	Accuracy        float64                `json:"accuracy"`
+	DurationSeconds float64                `json:"duration_seconds"`
+	Consistency     float64                `json:"consistency"`
+	LetterAccuracy  map[string]LetterStats `json:"letter_accuracy,omitempty"`
+	QuoteID         int                    `json:"quote_id,omitempty"` // Quote typed, if any
+}
+
+// NewRoundSummary builds a RoundSummary from a completed session
+func NewRoundSummary(session *Stats) RoundSummary {
+	timestamp := session.EndTime
+	if timestamp.IsZero() {
+		timestamp = time.Now()
+	}
+
+	letters := make(map[string]LetterStats, len(session.LetterAccuracy))
+	for letter, letterStats := range session.LetterAccuracy {
+		letters[letter] = letterStats
+	}
+
+	return RoundSummary{
+		Timestamp:       timestamp,
+		WPM:             session.WPM,
+		NetWPM:          session.NetWPM,
+		Accuracy:        session.Accuracy,
+		DurationSeconds: session.Duration.Seconds(),
+		Consistency:     session.Consistency,
+		LetterAccuracy:  letters,
+		QuoteID:         session.QuoteID,
+	}
+}
+
+// RecordRound appends a round summary, trimming the oldest entries beyond MaxRoundHistory
+func (h *HistoricalStats) RecordRound(summary RoundSummary) {
+	h.Rounds = append(h.Rounds, summary)
+	if len(h.Rounds) > MaxRoundHistory {
+		h.Rounds = h.Rounds[len(h.Rounds)-MaxRoundHistory:]
+	}
+}
+
+// LetterTrend compares recent accuracy for a letter with earlier rounds
+type LetterTrend struct {
+	RecentAccuracy  float64 `json:"recent_accuracy"`  // Accuracy over the last ShortTrendWindow rounds
+	EarlierAccuracy float64 `json:"earlier_accuracy"` // Accuracy over all earlier recorded rounds
+	Change          float64 `json:"change"`           // Recent minus earlier (positive = improving)
+}
+
+// TrendReport summarises progress over the recorded round history
+type TrendReport struct {
+	Rounds                int                    `json:"rounds"`                   // Number of rounds analysed
+	RollingWPM10          float64                `json:"rolling_wpm_10"`           // Average WPM over the last 10 rounds
+	RollingWPM50          float64                `json:"rolling_wpm_50"`           // Average WPM over the last 50 rounds
+	RollingAccuracy10     float64                `json:"rolling_accuracy_10"`      // Average accuracy over the last 10 rounds
+	RollingAccuracy50     float64                `json:"rolling_accuracy_50"`      // Average accuracy over the last 50 rounds
+	WPMSeries10           []float64              `json:"wpm_series_10"`            // Rolling 10-round average WPM after each round
+	WPMSeries50           []float64              `json:"wpm_series_50"`            // Rolling 50-round average WPM after each round
+	ImprovementWPMPerWeek float64                `json:"improvement_wpm_per_week"` // Linear regression slope of WPM over time
+	LetterTrends          map[string]LetterTrend `json:"letter_trends"`            // Per-letter accuracy trends
+}
+
+// Trends computes rolling averages, the improvement rate and per-letter
+// accuracy trends from the recorded round history
+func (h *HistoricalStats) Trends() TrendReport {
+	return ComputeTrends(h.Rounds)
+}
+
+// ComputeTrends computes a TrendReport from a time-ordered series of rounds
+func ComputeTrends(rounds []RoundSummary) TrendReport {
+	report := TrendReport{
+		Rounds:       len(rounds),
+		WPMSeries10:  make([]float64, 0, len(rounds)),
+		WPMSeries50:  make([]float64, 0, len(rounds)),
+		LetterTrends: make(map[string]LetterTrend),
+	}
+	if len(rounds) == 0 {
+		return report
+	}
+
+	wpms := make([]float64, len(rounds))
+	accuracies := make([]float64, len(rounds))
+	for i, round := range rounds {
+		wpms[i] = round.WPM
+		accuracies[i] = round.Accuracy
+	}
+
+	report.WPMSeries10 = rollingAverages(wpms, ShortTrendWindow)
+	report.WPMSeries50 = rollingAverages(wpms, LongTrendWindow)
+	report.RollingWPM10 = report.WPMSeries10[len(report.WPMSeries10)-1]
+	report.RollingWPM50 = report.WPMSeries50[len(report.WPMSeries50)-1]
+	report.RollingAccuracy10 = meanOf(accuracies[windowStart(len(accuracies), ShortTrendWindow):])
+	report.RollingAccuracy50 = meanOf(accuracies[windowStart(len(accuracies), LongTrendWindow):])
+	report.ImprovementWPMPerWeek = improvementPerWeek(rounds)
+	report.LetterTrends = letterTrends(rounds)
+
+	return report
+}
+
+// windowStart returns the index at which the trailing window of the given size begins
+func windowStart(length, window int) int {
+	if length <= window {
+		return 0
+	}
+	return length - window
+}
+
+// rollingAverages returns the trailing average at each point in the series
+func rollingAverages(values []float64, window int) []float64 {
+	result := make([]float64, len(values))
+	var sum float64
+	for i, v := range values {
+		sum += v
+		if i >= window {
+			sum -= values[i-window]
+		}
+		count := i + 1
+		if count > window {
+			count = window
+		}
+		result[i] = sum / float64(count)
+	}
+	return result
+}
+
+// meanOf returns the mean of a series of values
+func meanOf(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
+
+// improvementPerWeek fits a least-squares line of WPM against time and returns
+// its slope in WPM per week
+func improvementPerWeek(rounds []RoundSummary) float64 {
+	if len(rounds) < 2 {
+		return 0
+	}
+
+	start := rounds[0].Timestamp
+	var sumX, sumY, sumXY, sumXX float64
+	for _, round := range rounds {
+		x := round.Timestamp.Sub(start).Hours() / (24 * 7) // Weeks since first round
+		y := round.WPM
+		sumX += x
+		sumY += y
+		sumXY += x * y
+		sumXX += x * x
+	}
+
+	n := float64(len(rounds))
+	denominator := n*sumXX - sumX*sumX
+	if denominator <= 0 {
+		return 0 // All rounds at the same instant
+	}
+	return (n*sumXY - sumX*sumY) / denominator
+}
+
+// letterTrends compares per-letter accuracy in the most recent rounds with earlier rounds
+func letterTrends(rounds []RoundSummary) map[string]LetterTrend {
+	trends := make(map[string]LetterTrend)
+	split := windowStart(len(rounds), ShortTrendWindow)
+	if split == 0 {
+		return trends // Not enough history to compare
+	}
+
+	earlier := sumLetterAccuracy(rounds[:split])
+	recent := sumLetterAccuracy(rounds[split:])
+	for letter, recentStats := range recent {
+		earlierStats, ok := earlier[letter]
+		if !ok || recentStats.Presented == 0 || earlierStats.Presented == 0 {
+			continue
+		}
+		recentAcc := float64(recentStats.Correct) / float64(recentStats.Presented) * 100
+		earlierAcc := float64(earlierStats.Correct) / float64(earlierStats.Presented) * 100
+		trends[letter] = LetterTrend{
+			RecentAccuracy:  recentAcc,
+			EarlierAccuracy: earlierAcc,
+			Change:          recentAcc - earlierAcc,
+		}
+	}
+	return trends
+}
+
+// sumLetterAccuracy totals per-letter accuracy across a set of rounds
+func sumLetterAccuracy(rounds []RoundSummary) map[string]LetterStats {
+	totals := make(map[string]LetterStats)
+	for _, round := range rounds {
+		for letter, letterStats := range round.LetterAccuracy {
+			total := totals[letter]
+			total.Presented += letterStats.Presented
+			total.Correct += letterStats.Correct
+			totals[letter] = total
+		}
+	}
+	return totals
+}
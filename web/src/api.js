@@ -1,17 +1,35 @@
 // API client for Baboon backend
 
 const API_BASE = '/api';
+const TOKEN_KEY = 'baboon.token';
+
+// The API token (see `baboon token`) is read from a ?token= link once and
+// remembered, so a tablet only needs the link opened a single time.
+function loadToken() {
+  const fromUrl = new URLSearchParams(window.location.search).get('token');
+  if (fromUrl) {
+    window.localStorage.setItem(TOKEN_KEY, fromUrl);
+    return fromUrl;
+  }
+  return window.localStorage.getItem(TOKEN_KEY);
+}
 
 class BaboonAPI {
   constructor() {
     this.sessionId = null;
     this.baseUrl = API_BASE;
+    this.token = loadToken();
+  }
+
+  headers(extra = {}) {
+    if (!this.token) return extra;
+    return { ...extra, Authorization: `Bearer ${this.token}` };
   }
 
   async createSession(punctuationMode = false) {
     const response = await fetch(`${this.baseUrl}/sessions`, {
       method: 'POST',
-      headers: { 'Content-Type': 'application/json' },
+      headers: this.headers({ 'Content-Type': 'application/json' }),
       body: JSON.stringify({ punctuation_mode: punctuationMode }),
     });
     const data = await response.json();
@@ -23,6 +41,7 @@ class BaboonAPI {
     if (!this.sessionId) return;
     await fetch(`${this.baseUrl}/sessions/${this.sessionId}`, {
       method: 'DELETE',
+      headers: this.headers(),
     });
     this.sessionId = null;
   }
@@ -30,6 +49,7 @@ class BaboonAPI {
   async startRound() {
     const response = await fetch(`${this.baseUrl}/sessions/${this.sessionId}/round`, {
       method: 'POST',
+      headers: this.headers(),
     });
     return response.json();
   }
@@ -37,7 +57,7 @@ class BaboonAPI {
   async processKeystroke(char, seekTimeMs = 0) {
     const response = await fetch(`${this.baseUrl}/sessions/${this.sessionId}/keystroke`, {
       method: 'POST',
-      headers: { 'Content-Type': 'application/json' },
+      headers: this.headers({ 'Content-Type': 'application/json' }),
       body: JSON.stringify({ char, seek_time_ms: seekTimeMs }),
     });
     return response.json();
@@ -46,6 +66,7 @@ class BaboonAPI {
   async processBackspace() {
     const response = await fetch(`${this.baseUrl}/sessions/${this.sessionId}/backspace`, {
       method: 'POST',
+      headers: this.headers(),
     });
     return response.json();
   }
@@ -53,16 +74,26 @@ class BaboonAPI {
   async processSpace(seekTimeMs = 0) {
     const response = await fetch(`${this.baseUrl}/sessions/${this.sessionId}/space`, {
       method: 'POST',
-      headers: { 'Content-Type': 'application/json' },
+      headers: this.headers({ 'Content-Type': 'application/json' }),
       body: JSON.stringify({ seek_time_ms: seekTimeMs }),
     });
     return response.json();
   }
 
+  // events: [{ type: 'keystroke' | 'backspace' | 'space' | 'pause' | 'start', char, seek_time_ms }]
+  async sendInput(events) {
+    const response = await fetch(`${this.baseUrl}/sessions/${this.sessionId}/input`, {
+      method: 'POST',
+      headers: this.headers({ 'Content-Type': 'application/json' }),
+      body: JSON.stringify({ events }),
+    });
+    return response.json();
+  }
+
   async submitTiming(startTimeMs, endTimeMs, durationMs) {
     const response = await fetch(`${this.baseUrl}/sessions/${this.sessionId}/timing`, {
       method: 'POST',
-      headers: { 'Content-Type': 'application/json' },
+      headers: this.headers({ 'Content-Type': 'application/json' }),
       body: JSON.stringify({
         start_time_unix_ms: startTimeMs,
         end_time_unix_ms: endTimeMs,
@@ -73,23 +104,29 @@ class BaboonAPI {
   }
 
   async getState() {
-    const response = await fetch(`${this.baseUrl}/sessions/${this.sessionId}/state`);
+    const response = await fetch(`${this.baseUrl}/sessions/${this.sessionId}/state`, { headers: this.headers() });
     return response.json();
   }
 
   async getSessionStats() {
-    const response = await fetch(`${this.baseUrl}/sessions/${this.sessionId}/stats/session`);
+    const response = await fetch(`${this.baseUrl}/sessions/${this.sessionId}/stats/session`, { headers: this.headers() });
     return response.json();
   }
 
   async getHistoricalStats() {
-    const response = await fetch(`${this.baseUrl}/sessions/${this.sessionId}/stats/historical`);
+    const response = await fetch(`${this.baseUrl}/sessions/${this.sessionId}/stats/historical`, { headers: this.headers() });
+    return response.json();
+  }
+
+  async getTrends() {
+    const response = await fetch(`${this.baseUrl}/sessions/${this.sessionId}/stats/trends`, { headers: this.headers() });
     return response.json();
   }
 
   async saveStats() {
     const response = await fetch(`${this.baseUrl}/sessions/${this.sessionId}/save`, {
       method: 'POST',
+      headers: this.headers(),
     });
     return response.json();
   }
@@ -8,8 +8,11 @@ export default defineConfig({
     port: 3000,
     proxy: {
       '/api': {
-        target: 'http://localhost:8787',
+        // Set BABOON_API=https://localhost:8787 for a backend started with -tls
+        target: process.env.BABOON_API || 'http://localhost:8787',
         changeOrigin: true,
+        // Accept the backend's self-signed certificate
+        secure: false,
       },
     },
   },
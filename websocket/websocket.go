@@ -0,0 +1,419 @@
+// Package websocket implements the subset of the WebSocket protocol (RFC 6455)
+// Baboon needs: the opening handshake on both sides, text messages, and the
+// ping, pong and close control frames. Extensions are not supported.
+package websocket
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MaxMessageSize is the largest message accepted from the peer
+const MaxMessageSize = 1 << 20
+
+// acceptGUID is appended to the client's key to compute Sec-WebSocket-Accept
+const acceptGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// Frame opcodes
+const (
+	opContinuation = 0x0
+	opText         = 0x1
+	opBinary       = 0x2
+	opClose        = 0x8
+	opPing         = 0x9
+	opPong         = 0xA
+)
+
+// Close status codes
+const (
+	closeNormal        = 1000
+	closeProtocolError = 1002
+	closeTooBig        = 1009
+)
+
+// ErrClosed is returned when reading from a connection the peer has closed
+var ErrClosed = errors.New("websocket: connection closed")
+
+// Conn is a WebSocket connection. ReadMessage must only be called from one
+// goroutine at a time; WriteMessage and Close are safe for concurrent use.
+type Conn struct {
+	conn        net.Conn
+	br          *bufio.Reader
+	client      bool   // Clients mask the frames they send
+	subprotocol string // Negotiated subprotocol, if any
+
+	writeMu   sync.Mutex
+	closeOnce sync.Once
+}
+
+// Subprotocol returns the subprotocol agreed in the handshake, or "" if none.
+func (c *Conn) Subprotocol() string {
+	return c.subprotocol
+}
+
+// SetDeadline sets the read and write deadline of the underlying connection.
+func (c *Conn) SetDeadline(t time.Time) error {
+	return c.conn.SetDeadline(t)
+}
+
+// Accept completes the server side of the opening handshake and takes over
+// the connection from the HTTP server. If the client offers subprotocols,
+// one of them must be subprotocol. On failure an HTTP error has already been
+// written to w.
+func Accept(w http.ResponseWriter, r *http.Request, subprotocol string) (*Conn, error) {
+	if r.Method != http.MethodGet ||
+		!headerContains(r.Header, "Connection", "upgrade") ||
+		!headerContains(r.Header, "Upgrade", "websocket") {
+		http.Error(w, "websocket upgrade required", http.StatusUpgradeRequired)
+		return nil, errors.New("websocket: not an upgrade request")
+	}
+	if r.Header.Get("Sec-WebSocket-Version") != "13" {
+		w.Header().Set("Sec-WebSocket-Version", "13")
+		http.Error(w, "unsupported websocket version", http.StatusUpgradeRequired)
+		return nil, errors.New("websocket: unsupported version")
+	}
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if key == "" {
+		http.Error(w, "missing Sec-WebSocket-Key", http.StatusBadRequest)
+		return nil, errors.New("websocket: missing key")
+	}
+
+	agreed := ""
+	if offered := r.Header.Values("Sec-WebSocket-Protocol"); len(offered) > 0 {
+		if !headerContains(r.Header, "Sec-WebSocket-Protocol", subprotocol) {
+			http.Error(w, "unsupported websocket subprotocol", http.StatusBadRequest)
+			return nil, fmt.Errorf("websocket: client does not speak %s", subprotocol)
+		}
+		agreed = subprotocol
+	}
+
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "websocket not supported", http.StatusInternalServerError)
+		return nil, errors.New("websocket: response does not support hijacking")
+	}
+	conn, rw, err := hijacker.Hijack()
+	if err != nil {
+		return nil, err
+	}
+
+	var resp strings.Builder
+	resp.WriteString("HTTP/1.1 101 Switching Protocols\r\n")
+	resp.WriteString("Upgrade: websocket\r\nConnection: Upgrade\r\n")
+	resp.WriteString("Sec-WebSocket-Accept: " + acceptKey(key) + "\r\n")
+	if agreed != "" {
+		resp.WriteString("Sec-WebSocket-Protocol: " + agreed + "\r\n")
+	}
+	resp.WriteString("\r\n")
+	if _, err := conn.Write([]byte(resp.String())); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return &Conn{conn: conn, br: rw.Reader, subprotocol: agreed}, nil
+}
+
+// Dialer opens client connections.
+type Dialer struct {
+	// Timeout bounds connecting and the opening handshake
+	Timeout time.Duration
+
+	// Subprotocol is requested in the handshake and must be agreed by the server
+	Subprotocol string
+
+	// Header holds extra handshake headers, such as Authorization
+	Header http.Header
+
+	// TLSConfig is used for wss:// URLs; nil uses the default configuration
+	TLSConfig *tls.Config
+}
+
+// Dial connects to a ws:// or wss:// URL and performs the opening handshake.
+func (d Dialer) Dial(rawURL string) (*Conn, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	var defaultPort string
+	switch u.Scheme {
+	case "ws":
+		defaultPort = "80"
+	case "wss":
+		defaultPort = "443"
+	default:
+		return nil, fmt.Errorf("websocket: unsupported scheme %q", u.Scheme)
+	}
+	host := u.Host
+	if u.Port() == "" {
+		host = net.JoinHostPort(u.Hostname(), defaultPort)
+	}
+
+	netDialer := &net.Dialer{Timeout: d.Timeout}
+	var conn net.Conn
+	if u.Scheme == "wss" {
+		config := &tls.Config{}
+		if d.TLSConfig != nil {
+			config = d.TLSConfig.Clone()
+		}
+		if config.ServerName == "" {
+			config.ServerName = u.Hostname()
+		}
+		// The handshake is HTTP/1.1 only; a server offered h2 may choose it
+		config.NextProtos = []string{"http/1.1"}
+		conn, err = tls.DialWithDialer(netDialer, "tcp", host, config)
+	} else {
+		conn, err = netDialer.Dial("tcp", host)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if d.Timeout > 0 {
+		conn.SetDeadline(time.Now().Add(d.Timeout))
+	}
+
+	nonce := make([]byte, 16)
+	rand.Read(nonce)
+	key := base64.StdEncoding.EncodeToString(nonce)
+
+	req, _ := http.NewRequest(http.MethodGet, u.String(), nil)
+	for name, values := range d.Header {
+		req.Header[name] = values
+	}
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	req.Header.Set("Sec-WebSocket-Key", key)
+	if d.Subprotocol != "" {
+		req.Header.Set("Sec-WebSocket-Protocol", d.Subprotocol)
+	}
+	if err := req.Write(conn); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, req)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		conn.Close()
+		return nil, fmt.Errorf("websocket: handshake failed with status %d", resp.StatusCode)
+	}
+	if resp.Header.Get("Sec-WebSocket-Accept") != acceptKey(key) {
+		conn.Close()
+		return nil, errors.New("websocket: invalid Sec-WebSocket-Accept")
+	}
+	agreed := resp.Header.Get("Sec-WebSocket-Protocol")
+	if d.Subprotocol != "" && agreed != d.Subprotocol {
+		conn.Close()
+		return nil, fmt.Errorf("websocket: server did not agree to %s", d.Subprotocol)
+	}
+
+	conn.SetDeadline(time.Time{})
+	return &Conn{conn: conn, br: br, client: true, subprotocol: agreed}, nil
+}
+
+// ReadMessage returns the next text or binary message. Pings are answered
+// while waiting. It returns ErrClosed once the peer has closed the connection.
+func (c *Conn) ReadMessage() ([]byte, error) {
+	var message []byte
+	started := false
+	for {
+		fin, opcode, payload, err := c.readFrame()
+		if err != nil {
+			return nil, err
+		}
+
+		switch opcode {
+		case opPing:
+			if err := c.writeFrame(opPong, payload); err != nil {
+				return nil, err
+			}
+		case opPong:
+		case opClose:
+			c.writeFrame(opClose, payload)
+			c.conn.Close()
+			return nil, ErrClosed
+		case opText, opBinary, opContinuation:
+			if (opcode == opContinuation) != started {
+				c.fail(closeProtocolError)
+				return nil, errors.New("websocket: unexpected continuation frame")
+			}
+			started = true
+			if len(message)+len(payload) > MaxMessageSize {
+				c.fail(closeTooBig)
+				return nil, errors.New("websocket: message too large")
+			}
+			message = append(message, payload...)
+			if fin {
+				return message, nil
+			}
+		default:
+			c.fail(closeProtocolError)
+			return nil, fmt.Errorf("websocket: unknown opcode %d", opcode)
+		}
+	}
+}
+
+// WriteMessage sends data as a single text message.
+func (c *Conn) WriteMessage(data []byte) error {
+	return c.writeFrame(opText, data)
+}
+
+// Close sends a close frame and closes the connection.
+func (c *Conn) Close() error {
+	err := net.ErrClosed
+	c.closeOnce.Do(func() {
+		c.conn.SetWriteDeadline(time.Now().Add(time.Second))
+		c.writeFrame(opClose, closePayload(closeNormal))
+		err = c.conn.Close()
+	})
+	return err
+}
+
+// fail closes the connection after a protocol violation by the peer.
+func (c *Conn) fail(code int) {
+	c.closeOnce.Do(func() {
+		c.writeFrame(opClose, closePayload(code))
+		c.conn.Close()
+	})
+}
+
+// readFrame reads one frame, unmasking its payload.
+func (c *Conn) readFrame() (fin bool, opcode byte, payload []byte, err error) {
+	var header [2]byte
+	if _, err = io.ReadFull(c.br, header[:]); err != nil {
+		return
+	}
+	fin = header[0]&0x80 != 0
+	opcode = header[0] & 0x0F
+	masked := header[1]&0x80 != 0
+	if header[0]&0x70 != 0 {
+		c.fail(closeProtocolError)
+		return false, 0, nil, errors.New("websocket: reserved bits set")
+	}
+	// Clients must mask every frame; servers must not
+	if masked == c.client {
+		c.fail(closeProtocolError)
+		return false, 0, nil, errors.New("websocket: incorrect frame masking")
+	}
+
+	length := uint64(header[1] & 0x7F)
+	switch length {
+	case 126:
+		var ext [2]byte
+		if _, err = io.ReadFull(c.br, ext[:]); err != nil {
+			return
+		}
+		length = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err = io.ReadFull(c.br, ext[:]); err != nil {
+			return
+		}
+		length = binary.BigEndian.Uint64(ext[:])
+	}
+	if opcode >= opClose && (length > 125 || !fin) {
+		c.fail(closeProtocolError)
+		return false, 0, nil, errors.New("websocket: invalid control frame")
+	}
+	if length > MaxMessageSize {
+		c.fail(closeTooBig)
+		return false, 0, nil, errors.New("websocket: frame too large")
+	}
+
+	var mask [4]byte
+	if masked {
+		if _, err = io.ReadFull(c.br, mask[:]); err != nil {
+			return
+		}
+	}
+	payload = make([]byte, length)
+	if _, err = io.ReadFull(c.br, payload); err != nil {
+		return
+	}
+	if masked {
+		for i := range payload {
+			payload[i] ^= mask[i%4]
+		}
+	}
+	return fin, opcode, payload, nil
+}
+
+// writeFrame sends a single unfragmented frame.
+func (c *Conn) writeFrame(opcode byte, payload []byte) error {
+	frame := make([]byte, 0, len(payload)+14)
+	frame = append(frame, 0x80|opcode)
+
+	maskBit := byte(0)
+	if c.client {
+		maskBit = 0x80
+	}
+	switch {
+	case len(payload) < 126:
+		frame = append(frame, maskBit|byte(len(payload)))
+	case len(payload) <= 0xFFFF:
+		frame = append(frame, maskBit|126)
+		frame = binary.BigEndian.AppendUint16(frame, uint16(len(payload)))
+	default:
+		frame = append(frame, maskBit|127)
+		frame = binary.BigEndian.AppendUint64(frame, uint64(len(payload)))
+	}
+
+	if c.client {
+		var mask [4]byte
+		rand.Read(mask[:])
+		frame = append(frame, mask[:]...)
+		start := len(frame)
+		frame = append(frame, payload...)
+		for i := range payload {
+			frame[start+i] ^= mask[i%4]
+		}
+	} else {
+		frame = append(frame, payload...)
+	}
+
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	_, err := c.conn.Write(frame)
+	return err
+}
+
+// closePayload encodes a close status code.
+func closePayload(code int) []byte {
+	return binary.BigEndian.AppendUint16(nil, uint16(code))
+}
+
+// acceptKey computes the Sec-WebSocket-Accept value for a client key.
+func acceptKey(key string) string {
+	sum := sha1.Sum([]byte(key + acceptGUID))
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// headerContains reports whether a comma-separated header contains token,
+// ignoring case.
+func headerContains(h http.Header, name, token string) bool {
+	for _, value := range h.Values(name) {
+		for _, part := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(part), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
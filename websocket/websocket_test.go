@@ -0,0 +1,305 @@
+package websocket
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testMask is the masking key used for frames the tests send as a client
+var testMask = [4]byte{0x12, 0x34, 0x56, 0x78}
+
+// received is a frame the server sent to the raw client end of a pipe
+type received struct {
+	fin     bool
+	opcode  byte
+	payload []byte
+}
+
+// pipe returns the server end of an in-memory connection and the raw client
+// end. Frames the server sends are parsed and delivered on the channel, which
+// is closed once the server closes the connection.
+func pipe(t *testing.T) (*Conn, net.Conn, <-chan received) {
+	t.Helper()
+	server, client := net.Pipe()
+	t.Cleanup(func() {
+		server.Close()
+		client.Close()
+	})
+	server.SetDeadline(time.Now().Add(5 * time.Second))
+	client.SetDeadline(time.Now().Add(5 * time.Second))
+
+	frames := make(chan received, 16)
+	go func() {
+		defer close(frames)
+		peer := &Conn{conn: client, br: bufio.NewReader(client), client: true}
+		for {
+			fin, opcode, payload, err := peer.readFrame()
+			if err != nil {
+				return
+			}
+			frames <- received{fin, opcode, payload}
+		}
+	}()
+	return &Conn{conn: server, br: bufio.NewReader(server)}, client, frames
+}
+
+// frame encodes a frame as a client would send it, masked unless told not to
+func frame(fin bool, opcode byte, masked bool, payload []byte) []byte {
+	first := opcode
+	if fin {
+		first |= 0x80
+	}
+	maskBit := byte(0)
+	if masked {
+		maskBit = 0x80
+	}
+	out := []byte{first}
+	switch {
+	case len(payload) < 126:
+		out = append(out, maskBit|byte(len(payload)))
+	case len(payload) <= 0xFFFF:
+		out = append(out, maskBit|126)
+		out = binary.BigEndian.AppendUint16(out, uint16(len(payload)))
+	default:
+		out = append(out, maskBit|127)
+		out = binary.BigEndian.AppendUint64(out, uint64(len(payload)))
+	}
+	if !masked {
+		return append(out, payload...)
+	}
+	out = append(out, testMask[:]...)
+	for i, b := range payload {
+		out = append(out, b^testMask[i%4])
+	}
+	return out
+}
+
+// send writes frames to the raw client end without waiting for the server
+// to read them; writes after the server has closed are dropped
+func send(client net.Conn, frames ...[]byte) {
+	go func() {
+		for _, f := range frames {
+			if _, err := client.Write(f); err != nil {
+				return
+			}
+		}
+	}()
+}
+
+// wantClose waits for the server's close frame and checks its status code
+func wantClose(t *testing.T, frames <-chan received, code int) {
+	t.Helper()
+	for f := range frames {
+		if f.opcode != opClose {
+			continue
+		}
+		if len(f.payload) < 2 || int(binary.BigEndian.Uint16(f.payload)) != code {
+			t.Errorf("close payload = %v, want status %d", f.payload, code)
+		}
+		return
+	}
+	t.Errorf("connection ended without a close frame, want status %d", code)
+}
+
+func TestHandshake(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := Accept(w, r, "baboon.v1")
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		if message, err := conn.ReadMessage(); err == nil {
+			conn.WriteMessage(append([]byte("echo: "), message...))
+		}
+	}))
+	defer srv.Close()
+
+	url := "ws" + strings.TrimPrefix(srv.URL, "http")
+	conn, err := Dialer{Timeout: 5 * time.Second, Subprotocol: "baboon.v1"}.Dial(url)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+	if conn.Subprotocol() != "baboon.v1" {
+		t.Errorf("subprotocol = %q, want baboon.v1", conn.Subprotocol())
+	}
+	if err := conn.WriteMessage([]byte("hello")); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	message, err := conn.ReadMessage()
+	if err != nil || string(message) != "echo: hello" {
+		t.Errorf("read = %q, %v; want %q", message, err, "echo: hello")
+	}
+
+	if _, err := (Dialer{Subprotocol: "other"}).Dial(url); err == nil {
+		t.Error("dial with an unsupported subprotocol succeeded")
+	}
+}
+
+func TestClientMasksFrames(t *testing.T) {
+	a, b := net.Pipe()
+	defer a.Close()
+	defer b.Close()
+	client := &Conn{conn: a, client: true}
+	go client.WriteMessage([]byte("hello"))
+
+	raw := make([]byte, 2+4+5)
+	b.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if _, err := io.ReadFull(b, raw); err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if raw[0] != 0x80|opText || raw[1] != 0x80|5 {
+		t.Fatalf("header = %x, want a final masked text frame of 5 bytes", raw[:2])
+	}
+	payload := raw[6:]
+	if bytes.Equal(payload, []byte("hello")) {
+		t.Error("payload was sent unmasked")
+	}
+	for i := range payload {
+		payload[i] ^= raw[2+i%4]
+	}
+	if string(payload) != "hello" {
+		t.Errorf("unmasked payload = %q, want hello", payload)
+	}
+}
+
+func TestServerRejectsUnmaskedFrames(t *testing.T) {
+	server, client, frames := pipe(t)
+	send(client, frame(true, opText, false, []byte("hello")))
+
+	if _, err := server.ReadMessage(); err == nil {
+		t.Fatal("unmasked frame was accepted")
+	}
+	wantClose(t, frames, closeProtocolError)
+}
+
+func TestFragmentedMessage(t *testing.T) {
+	server, client, frames := pipe(t)
+	// A ping may arrive between the fragments of a message
+	send(client,
+		frame(false, opText, true, []byte("hel")),
+		frame(true, opPing, true, []byte("are you there")),
+		frame(false, opContinuation, true, []byte("lo ")),
+		frame(true, opContinuation, true, []byte("world")),
+		frame(true, opText, true, []byte("next")),
+	)
+
+	for _, want := range []string{"hello world", "next"} {
+		message, err := server.ReadMessage()
+		if err != nil || string(message) != want {
+			t.Fatalf("read = %q, %v; want %q", message, err, want)
+		}
+	}
+	pong := <-frames
+	if pong.opcode != opPong || string(pong.payload) != "are you there" {
+		t.Errorf("reply to ping = opcode %d %q, want a pong echoing the payload", pong.opcode, pong.payload)
+	}
+}
+
+func TestUnexpectedContinuation(t *testing.T) {
+	for name, frames := range map[string][][]byte{
+		"continuation first": {frame(true, opContinuation, true, []byte("x"))},
+		"new message mid-fragment": {
+			frame(false, opText, true, []byte("a")),
+			frame(true, opText, true, []byte("b")),
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			server, client, received := pipe(t)
+			send(client, frames...)
+			if _, err := server.ReadMessage(); err == nil {
+				t.Fatal("out of order fragment was accepted")
+			}
+			wantClose(t, received, closeProtocolError)
+		})
+	}
+}
+
+func TestInvalidControlFrames(t *testing.T) {
+	for name, f := range map[string][]byte{
+		"long ping":       frame(true, opPing, true, bytes.Repeat([]byte("x"), 126)),
+		"fragmented ping": frame(false, opPing, true, []byte("x")),
+		"reserved bits":   append([]byte{0x80 | 0x40 | opText}, frame(true, opText, true, []byte("x"))[1:]...),
+		"unknown opcode":  frame(true, 0x3, true, []byte("x")),
+	} {
+		t.Run(name, func(t *testing.T) {
+			server, client, frames := pipe(t)
+			send(client, f)
+			if _, err := server.ReadMessage(); err == nil {
+				t.Fatal("invalid frame was accepted")
+			}
+			wantClose(t, frames, closeProtocolError)
+		})
+	}
+}
+
+func TestMaxMessageSize(t *testing.T) {
+	half := bytes.Repeat([]byte("x"), MaxMessageSize/2)
+
+	t.Run("at the limit", func(t *testing.T) {
+		server, client, _ := pipe(t)
+		send(client, frame(false, opText, true, half), frame(true, opContinuation, true, half))
+		message, err := server.ReadMessage()
+		if err != nil || len(message) != MaxMessageSize {
+			t.Fatalf("read %d bytes, %v; want %d", len(message), err, MaxMessageSize)
+		}
+	})
+
+	t.Run("single frame", func(t *testing.T) {
+		server, client, frames := pipe(t)
+		send(client, frame(true, opText, true, bytes.Repeat([]byte("x"), MaxMessageSize+1)))
+		if _, err := server.ReadMessage(); err == nil {
+			t.Fatal("oversized frame was accepted")
+		}
+		wantClose(t, frames, closeTooBig)
+	})
+
+	t.Run("fragments", func(t *testing.T) {
+		server, client, frames := pipe(t)
+		send(client,
+			frame(false, opText, true, half),
+			frame(false, opContinuation, true, half),
+			frame(true, opContinuation, true, []byte("x")),
+		)
+		if _, err := server.ReadMessage(); err == nil {
+			t.Fatal("oversized fragmented message was accepted")
+		}
+		wantClose(t, frames, closeTooBig)
+	})
+}
+
+func TestPeerClose(t *testing.T) {
+	server, client, frames := pipe(t)
+	send(client, frame(true, opClose, true, closePayload(closeNormal)))
+
+	if _, err := server.ReadMessage(); !errors.Is(err, ErrClosed) {
+		t.Fatalf("read after close frame = %v, want ErrClosed", err)
+	}
+	wantClose(t, frames, closeNormal)
+	if _, ok := <-frames; ok {
+		t.Error("connection still open after the close handshake")
+	}
+}
+
+func TestClose(t *testing.T) {
+	server, _, frames := pipe(t)
+	if err := server.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	wantClose(t, frames, closeNormal)
+	if err := server.Close(); !errors.Is(err, net.ErrClosed) {
+		t.Errorf("second close = %v, want net.ErrClosed", err)
+	}
+	if err := server.WriteMessage([]byte("late")); err == nil {
+		t.Error("write after close succeeded")
+	}
+}
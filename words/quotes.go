@@ -0,0 +1,92 @@
+package words
+
+import (
+	_ "embed"
+	"encoding/json"
+	"strings"
+)
+
+// quotesJSON is the bundled quote corpus. Quote IDs key the per-quote best
+// times in historical stats, so an ID must never be reused for a different
+// passage.
+//
+//go:embed quotes.json
+var quotesJSON []byte
+
+// Quote length categories, by the number of characters in the passage
+const (
+	QuoteLengthAny    = ""       // Any length
+	QuoteLengthShort  = "short"  // Fewer than 100 characters
+	QuoteLengthMedium = "medium" // 100 to 249 characters
+	QuoteLengthLong   = "long"   // 250 characters or more
+)
+
+// Quote is a passage from the bundled corpus
+type Quote struct {
+	ID     int    `json:"id"`
+	Text   string `json:"text"`
+	Source string `json:"source"` // Author and work the passage is taken from
+}
+
+// Quotes contains every passage in the bundled corpus
+var Quotes = loadQuotes()
+
+func loadQuotes() []Quote {
+	var quotes []Quote
+	if err := json.Unmarshal(quotesJSON, &quotes); err != nil {
+		panic("words: invalid quote corpus: " + err.Error())
+	}
+	return quotes
+}
+
+// Length returns the quote's length category
+func (q Quote) Length() string {
+	switch n := len(q.Text); {
+	case n < 100:
+		return QuoteLengthShort
+	case n < 250:
+		return QuoteLengthMedium
+	default:
+		return QuoteLengthLong
+	}
+}
+
+// Words splits the quote into the words typed in a round. Punctuation and
+// capitals stay attached to their words, so they are typed as written.
+func (q Quote) Words() []string {
+	return strings.Fields(q.Text)
+}
+
+// ValidQuoteLength reports whether length is a known length category
+func ValidQuoteLength(length string) bool {
+	switch length {
+	case QuoteLengthAny, QuoteLengthShort, QuoteLengthMedium, QuoteLengthLong:
+		return true
+	}
+	return false
+}
+
+// RandomQuote returns a random quote of the given length category, or of any
+// length for QuoteLengthAny
+func RandomQuote(length string, rng func(int) int) Quote {
+	candidates := make([]Quote, 0, len(Quotes))
+	for _, q := range Quotes {
+		if length == QuoteLengthAny || q.Length() == length {
+			candidates = append(candidates, q)
+		}
+	}
+	if len(candidates) == 0 {
+		candidates = Quotes
+	}
+	return candidates[rng(len(candidates))]
+}
+
+// QuoteByID returns the quote with the given ID
+func QuoteByID(id int) (Quote, bool) {
+	for _, q := range Quotes {
+		if q.ID == id {
+			return q, true
+		}
+	}
+	return Quote{}, false
+}